@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/deepch/vdk/av"
+	"github.com/gin-gonic/gin"
+)
+
+// PathTrack은 경로(스트림)에 달린 트랙 하나의 코덱 정보를 담습니다.
+type PathTrack struct {
+	Type      string `json:"type"`
+	Codec     string `json:"codec"`
+	ClockRate int    `json:"clock_rate"`
+}
+
+// PathReader는 경로를 구독 중인 RTSP 클라이언트 하나를 설명합니다. 현재는
+// RTSPServer의 세션만 원격 주소/전송 방식을 추적하므로 RTSP 리더에 한정됩니다.
+type PathReader struct {
+	RemoteAddr string `json:"remote_addr"`
+	Transport  string `json:"transport"`
+}
+
+// PathInfo는 mediamtx의 /v3/paths/get 응답을 본떠, 이 모듈의 Config.Streams
+// 관점에서 경로 하나의 상태를 나타냅니다.
+type PathInfo struct {
+	Name           string       `json:"name"`
+	Ready          bool         `json:"ready"`
+	SourceProtocol string       `json:"source_protocol"`
+	Tracks         []PathTrack  `json:"tracks"`
+	Readers        []PathReader `json:"readers"`
+}
+
+// registerPathRoutes는 /api/v1/paths, /api/v1/paths/:name 엔드포인트를 등록합니다.
+// 이는 /stream/api/status, /stream/api/status/:uuid를 대체하는 새 REST 표면입니다.
+func registerPathRoutes(router *gin.Engine) {
+	router.GET("/api/v1/paths", handlePathsList)
+	router.GET("/api/v1/paths/:name", handlePathByName)
+}
+
+// handlePathsList는 등록된 모든 경로의 상태를 이름순으로 나열합니다.
+func handlePathsList(c *gin.Context) {
+	Config.mutex.RLock()
+	names := make([]string, 0, len(Config.Streams))
+	for name := range Config.Streams {
+		names = append(names, name)
+	}
+	Config.mutex.RUnlock()
+	sort.Strings(names)
+
+	items := make([]PathInfo, 0, len(names))
+	for _, name := range names {
+		items = append(items, buildPathInfo(name))
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "total": len(items)})
+}
+
+// handlePathByName은 단일 경로의 상태를 반환합니다.
+func handlePathByName(c *gin.Context) {
+	name := c.Param("name")
+	if !Config.ext(name) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "path not found"})
+		return
+	}
+	c.JSON(http.StatusOK, buildPathInfo(name))
+}
+
+// trackClockRate는 트랙의 RTP 클록 레이트를 반환합니다. 비디오(H264/H265)는 RFC
+// 표준값인 90kHz로 고정이지만, 오디오는 코덱마다 샘플레이트가 다르므로(AAC 44.1/48kHz
+// 등) codec data에서 실제 샘플레이트를 읽어야 합니다.
+func trackClockRate(codec av.CodecData) int {
+	if audio, ok := codec.(av.AudioCodecData); ok {
+		return audio.SampleRate()
+	}
+	return rtpClockRate
+}
+
+// buildPathInfo는 Config.Streams[name]의 코덱/상태와, 그 경로를 구독 중인
+// RTSPServer 세션들로부터 PathInfo를 조립합니다.
+func buildPathInfo(name string) PathInfo {
+	Config.mutex.RLock()
+	stream := Config.Streams[name]
+	Config.mutex.RUnlock()
+
+	sourceProtocol := stream.SourceProtocol
+	switch {
+	case stream.URL == "publisher":
+		sourceProtocol = "publish"
+	case sourceProtocol == "":
+		sourceProtocol = "rtsp"
+	}
+
+	tracks := make([]PathTrack, 0, len(stream.Codecs))
+	for _, codec := range stream.Codecs {
+		trackType := "audio"
+		if codec.Type().IsVideo() {
+			trackType = "video"
+		}
+		tracks = append(tracks, PathTrack{
+			Type:      trackType,
+			Codec:     strings.ToLower(fmt.Sprintf("%v", codec.Type())),
+			ClockRate: trackClockRate(codec),
+		})
+	}
+
+	return PathInfo{
+		Name:           name,
+		Ready:          stream.Status,
+		SourceProtocol: sourceProtocol,
+		Tracks:         tracks,
+		Readers:        pathReaders(name),
+	}
+}
+
+// pathReaders는 RTSPServer에 연결된 세션 중 해당 경로를 구독(PLAY) 중인
+// (퍼블리시 중이 아닌) 세션들을 원격 주소/전송 방식과 함께 돌려줍니다.
+func pathReaders(name string) []PathReader {
+	if rtspServer == nil {
+		return nil
+	}
+	rtspServer.sessionsMtx.RLock()
+	defer rtspServer.sessionsMtx.RUnlock()
+
+	readers := make([]PathReader, 0)
+	for _, session := range rtspServer.sessions {
+		if session.StreamUUID != name || session.Publishing {
+			continue
+		}
+		// ClientAddr는 UDP 세션에만 채워지므로, TCP interleaved 세션은 연결 주소를 씁니다.
+		remoteAddr := session.ClientAddr
+		if remoteAddr == "" && session.Conn != nil {
+			remoteAddr = session.Conn.RemoteAddr().String()
+		}
+		readers = append(readers, PathReader{
+			RemoteAddr: remoteAddr,
+			Transport:  session.Transport,
+		})
+	}
+	return readers
+}
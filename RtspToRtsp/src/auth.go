@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 역할 상수. StreamST.ACL의 키, AuthMiddleware의 경로별 요구 역할과 동일합니다.
+const (
+	roleNone    = ""
+	rolePlay    = "play"
+	rolePublish = "publish"
+	roleAdmin   = "admin"
+)
+
+// roleForPath는 요청 경로로부터 필요한 역할을 결정합니다.
+// 빈 문자열을 반환하면 인증이 필요 없는 경로입니다.
+func roleForPath(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/stream/api/broadcast"),
+		strings.HasPrefix(path, "/stream/api/status"),
+		strings.HasPrefix(path, "/stream/api/server"),
+		strings.HasPrefix(path, "/metrics"):
+		return roleAdmin
+	case strings.HasPrefix(path, "/whip"):
+		return rolePublish
+	case strings.HasPrefix(path, "/whep"),
+		strings.HasPrefix(path, "/hls"),
+		strings.HasPrefix(path, "/stream/webrtc"),
+		strings.HasPrefix(path, "/stream/rtsp"),
+		strings.HasPrefix(path, "/stream/codec"),
+		strings.HasPrefix(path, "/stream/player"):
+		return rolePlay
+	default:
+		return roleNone
+	}
+}
+
+// extractToken은 Authorization: Bearer 헤더 또는 ?token= 쿼리에서 토큰을 추출합니다.
+func extractToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return c.Query("token")
+}
+
+// validateStaticToken은 토큰이 설정된 정적 토큰 목록에 포함되는지 확인합니다.
+func validateStaticToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, t := range Config.Server.AuthTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// validateHMACToken은 ?token=<hex hmac>&exp=<unix seconds> 형식의 단기 서명 URL 토큰을
+// 검증합니다. 서명은 HMAC-SHA256(secret, uuid+"."+exp)로 계산됩니다.
+func validateHMACToken(c *gin.Context, uuid, token string) bool {
+	secret := Config.Server.AuthHMACSecret
+	if secret == "" || token == "" {
+		return false
+	}
+	expStr := c.Query("exp")
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil {
+		return false
+	}
+	if time.Now().Unix() > exp {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(uuid + "." + expStr))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(token))
+}
+
+// streamACLRequiresRole은 uuid 스트림이 role에 대한 ACL 항목을 정의했는지 확인합니다.
+// 전역 토큰/HMAC 비밀이 설정되지 않았더라도 스트림별 ACL만으로 인증을 요구할 수 있으므로,
+// AuthMiddleware가 "인증 비활성화" 단축 경로를 탈 수 있는지 판단하는 데 씁니다.
+func streamACLRequiresRole(uuid, role string) bool {
+	Config.mutex.RLock()
+	stream, ok := Config.Streams[uuid]
+	Config.mutex.RUnlock()
+	if !ok || stream.ACL == nil {
+		return false
+	}
+	_, has := stream.ACL[role]
+	return has
+}
+
+// checkStreamACL은 StreamST.ACL에 role 항목이 정의된 경우 token이 그 목록에 있는지 확인합니다.
+// role 항목이 없으면 이 스트림에 한해 허용합니다.
+func checkStreamACL(uuid, role, token string) bool {
+	Config.mutex.RLock()
+	stream, ok := Config.Streams[uuid]
+	Config.mutex.RUnlock()
+	if !ok || stream.ACL == nil {
+		return true
+	}
+	allowed, has := stream.ACL[role]
+	if !has {
+		return true
+	}
+	for _, t := range allowed {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthMiddleware는 정적 베어러 토큰, HMAC 서명 URL 토큰, 스트림별 ACL을 검증합니다.
+// AuthTokens와 AuthHMACSecret이 모두 비어 있으면 전역 토큰 검증은 하지 않고, 요청한
+// 스트림이 해당 역할의 ACL을 정의한 경우에만 그 ACL로 인증을 요구합니다. 둘 다 없으면
+// 기존 배포와 마찬가지로 인증 없이 통과합니다.
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := roleForPath(c.Request.URL.Path)
+		if role == roleNone {
+			c.Next()
+			return
+		}
+
+		uuid := c.Param("uuid")
+
+		// 전역 토큰/HMAC 비밀이 둘 다 비어 있어도, 이 스트림이 role에 대한 ACL을 정의했다면
+		// 그 ACL만으로 인증을 요구해야 합니다 - 그렇지 않으면 스트림별 ACL만 설정한 경우
+		// 조용히 전체 공개가 됩니다.
+		globalAuthConfigured := len(Config.Server.AuthTokens) > 0 || Config.Server.AuthHMACSecret != ""
+		if !globalAuthConfigured && (uuid == "" || !streamACLRequiresRole(uuid, role)) {
+			c.Next()
+			return
+		}
+
+		token := extractToken(c)
+
+		if globalAuthConfigured {
+			authenticated := validateStaticToken(token) || validateHMACToken(c, uuid, token)
+			if !authenticated {
+				c.Header("WWW-Authenticate", "Bearer")
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+				return
+			}
+		}
+
+		if uuid != "" && !checkStreamACL(uuid, role, token) {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepch/vdk/av"
+	"github.com/deepch/vdk/format/rtmp"
+	"github.com/gin-gonic/gin"
+)
+
+// BroadcastTarget은 한 스트림을 외부 RTMP/SRT 싱크로 재전송하는 목적지입니다.
+type BroadcastTarget struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"`
+	Connected bool   `json:"connected"`
+	BytesSent uint64 `json:"bytesSent"`
+	LastError string `json:"lastError,omitempty"`
+	cancel    chan bool
+}
+
+var (
+	broadcastTargets   = make(map[string]map[string]*BroadcastTarget)
+	broadcastTargetsMu sync.RWMutex
+)
+
+// supportedBroadcastScheme은 pushToTarget이 실제로 리먹싱해 내보낼 수 있는 URL
+// 스킴인지 확인합니다. AddBroadcastTarget과 pushToTarget이 같은 판단 기준을
+// 공유해, 지원하지 않는 스킴이 재연결 backoff 루프에서 영원히 실패하는 대신
+// 등록 시점에 바로 거부되도록 합니다.
+func supportedBroadcastScheme(url string) bool {
+	return strings.HasPrefix(url, "rtmp://") || strings.HasPrefix(url, "rtmps://")
+}
+
+// AddBroadcastTarget는 uuid 스트림에 새 푸시 목적지를 등록하고 재전송 고루틴을 시작합니다.
+// srt:// 목적지는 vdk가 rtmp/mpegts 리먹서만 제공하고 이 트리에는 SRT 프로토콜(UDP
+// 기반 전송 자체)을 여는 라이브러리가 전혀 없어 지원하지 않습니다 - mpegts.Muxer는
+// 이미 만들어진 io.Writer에 리먹싱할 뿐, SRT 연결 자체를 열어주지 않습니다. RTMP/RTMPS만
+// 지원되는 스킴으로 남겨두고, 그 외 스킴은 고루틴을 띄우기 전에 여기서 즉시 거부합니다.
+func AddBroadcastTarget(uuid, url string) (*BroadcastTarget, error) {
+	if !Config.ext(uuid) {
+		return nil, fmt.Errorf("stream not found: %s", uuid)
+	}
+	if !supportedBroadcastScheme(url) {
+		return nil, fmt.Errorf("unsupported broadcast target scheme (only rtmp:// and rtmps:// are supported): %s", url)
+	}
+
+	target := &BroadcastTarget{
+		ID:     pseudoUUID(),
+		URL:    url,
+		cancel: make(chan bool, 1),
+	}
+
+	broadcastTargetsMu.Lock()
+	if broadcastTargets[uuid] == nil {
+		broadcastTargets[uuid] = make(map[string]*BroadcastTarget)
+	}
+	broadcastTargets[uuid][target.ID] = target
+	broadcastTargetsMu.Unlock()
+
+	go runBroadcastTarget(uuid, target)
+	return target, nil
+}
+
+// RemoveBroadcastTarget는 재전송 고루틴을 중단하고 목적지를 제거합니다.
+func RemoveBroadcastTarget(uuid, id string) bool {
+	broadcastTargetsMu.Lock()
+	defer broadcastTargetsMu.Unlock()
+	targets, ok := broadcastTargets[uuid]
+	if !ok {
+		return false
+	}
+	target, ok := targets[id]
+	if !ok {
+		return false
+	}
+	target.cancel <- true
+	delete(targets, id)
+	return true
+}
+
+// ListBroadcastTargets는 StreamStatus JSON에 포함될 목적지 상태 스냅샷을 반환합니다.
+func ListBroadcastTargets(uuid string) []BroadcastTarget {
+	broadcastTargetsMu.RLock()
+	defer broadcastTargetsMu.RUnlock()
+	targets, ok := broadcastTargets[uuid]
+	if !ok {
+		return nil
+	}
+	result := make([]BroadcastTarget, 0, len(targets))
+	for _, t := range targets {
+		result = append(result, BroadcastTarget{
+			ID:        t.ID,
+			URL:       t.URL,
+			Connected: t.Connected,
+			BytesSent: t.BytesSent,
+			LastError: t.LastError,
+		})
+	}
+	return result
+}
+
+func setTargetState(uuid string, target *BroadcastTarget, connected bool, err error) {
+	broadcastTargetsMu.Lock()
+	defer broadcastTargetsMu.Unlock()
+	if _, ok := broadcastTargets[uuid][target.ID]; !ok {
+		return
+	}
+	target.Connected = connected
+	if err != nil {
+		target.LastError = err.Error()
+	}
+}
+
+// runBroadcastTarget는 Config.clAd로 구독한 패킷을 rtmp:// 목적지로 리먹싱하여 전송하고,
+// 실패 시 지수 백오프로 재연결합니다. srt://는 지원 대상에서 제외되어 있고
+// AddBroadcastTarget이 등록 시점에 이미 거부하므로 여기까지 도달하지 않습니다.
+func runBroadcastTarget(uuid string, target *BroadcastTarget) {
+	backoff := time.Second
+	const maxBackoff = 30 * time.Second
+
+	for {
+		select {
+		case <-target.cancel:
+			return
+		default:
+		}
+
+		err := pushToTarget(uuid, target)
+		setTargetState(uuid, target, false, err)
+		if err != nil {
+			Errorf("broadcast %s -> %s failed: %v", uuid, target.URL, err)
+		}
+
+		select {
+		case <-target.cancel:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func pushToTarget(uuid string, target *BroadcastTarget) error {
+	codecs := Config.coGe(uuid)
+	if codecs == nil {
+		return fmt.Errorf("codec data not ready for stream %s", uuid)
+	}
+
+	var writeHeader func([]av.CodecData) error
+	var writePacket func(av.Packet) error
+	var closeMuxer func() error
+
+	switch {
+	case strings.HasPrefix(target.URL, "rtmp://") || strings.HasPrefix(target.URL, "rtmps://"):
+		conn, err := rtmp.Dial(target.URL)
+		if err != nil {
+			return fmt.Errorf("rtmp dial: %v", err)
+		}
+		if err := conn.WriteHeader(codecs); err != nil {
+			conn.Close()
+			return fmt.Errorf("rtmp header: %v", err)
+		}
+		writeHeader = func(c []av.CodecData) error { return nil }
+		writePacket = conn.WritePacket
+		closeMuxer = conn.Close
+
+	default:
+		// srt://를 포함해 AddBroadcastTarget이 등록을 거부하지 않은 스킴은 여기서도
+		// 없어야 하지만, 혹시 Config 파일 등으로 직접 주입된 경우를 대비해 방어적으로
+		// 에러를 반환합니다(supportedBroadcastScheme 참고).
+		return fmt.Errorf("unsupported broadcast target scheme: %s", target.URL)
+	}
+	_ = writeHeader
+	defer closeMuxer()
+
+	setTargetState(uuid, target, true, nil)
+
+	reader := Config.clAd(uuid, "relay")
+	defer Config.clDe(uuid, "relay", reader)
+
+	for {
+		select {
+		case <-target.cancel:
+			return nil
+		default:
+		}
+
+		pck, ok := reader.Read(10 * time.Second)
+		if !ok {
+			return fmt.Errorf("no packets received from stream %s", uuid)
+		}
+		if err := writePacket(pck); err != nil {
+			return fmt.Errorf("write packet: %v", err)
+		}
+		broadcastTargetsMu.Lock()
+		target.BytesSent += uint64(len(pck.Data))
+		broadcastTargetsMu.Unlock()
+	}
+}
+
+// HTTPAPIServerBroadcastList는 /stream/api/broadcast/:uuid의 GET 목록 요청을 처리합니다.
+func HTTPAPIServerBroadcastList(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !Config.ext(uuid) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stream not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"targets": ListBroadcastTargets(uuid)})
+}
+
+// HTTPAPIServerBroadcastAdd는 새 푸시 목적지를 추가합니다.
+func HTTPAPIServerBroadcastAdd(c *gin.Context) {
+	uuid := c.Param("uuid")
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := c.BindJSON(&body); err != nil || body.URL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url is required"})
+		return
+	}
+	target, err := AddBroadcastTarget(uuid, body.URL)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, target)
+}
+
+// HTTPAPIServerBroadcastRemove는 /stream/api/broadcast/:uuid/:id의 DELETE 요청을 처리합니다.
+func HTTPAPIServerBroadcastRemove(c *gin.Context) {
+	uuid := c.Param("uuid")
+	id := c.Param("id")
+	if !RemoveBroadcastTarget(uuid, id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "broadcast target not found"})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// registerBroadcastRoutes는 /stream/api/broadcast/:uuid 엔드포인트를 등록합니다.
+func registerBroadcastRoutes(router *gin.Engine) {
+	router.GET("/stream/api/broadcast/:uuid", HTTPAPIServerBroadcastList)
+	router.POST("/stream/api/broadcast/:uuid", HTTPAPIServerBroadcastAdd)
+	router.DELETE("/stream/api/broadcast/:uuid/:id", HTTPAPIServerBroadcastRemove)
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"sync"
@@ -25,10 +24,48 @@ type ConfigST struct {
 	Server         ServerST            `json:"server"`
 	StreamDefaults StreamST            `json:"stream_defaults"`
 	API            APIST               `json:"api"`
+	Auth           AuthST              `json:"auth"`
+	Log            LogST               `json:"log"`
 	Streams        map[string]StreamST `json:"streams"`
 	LastError      error
 }
 
+// LogST는 레벨 있는 로거(Logger)의 출력 레벨과 목적지를 설정합니다.
+type LogST struct {
+	// Level은 "debug"/"info"/"warn"/"error" 중 하나입니다. 비어 있으면 "info".
+	Level string `json:"level"`
+	// Stdout이 true(기본값, 생략 시에도 true)면 표준 출력에도 씁니다.
+	Stdout *bool `json:"stdout"`
+	// Dir/Prefix는 회전 로그 파일이 쓰여질 디렉토리와 파일명 접두사입니다.
+	// Dir이 비어 있으면 main()이 "../logs"를 사용합니다.
+	Dir    string `json:"dir"`
+	Prefix string `json:"prefix"`
+	// MaxSizeMB가 넘으면 크기 기준으로 회전합니다. 0이면 기본값(10MB).
+	MaxSizeMB int `json:"max_size_mb"`
+	// RotateDaily가 true면 날짜가 바뀔 때도 회전합니다(크기와 무관하게).
+	RotateDaily bool `json:"rotate_daily"`
+	// MaxBackups는 gzip으로 압축되어 보관되는 회전된 로그 파일의 최대 개수입니다.
+	// 0이면 기본값(30)이고, 음수면 무제한 보관합니다.
+	MaxBackups int `json:"max_backups"`
+	// Syslog가 true면 syslog(로컬 syslogd)에도 씁니다(유닉스 계열 전용).
+	Syslog bool `json:"syslog"`
+}
+
+// AuthST는 RTSPServer(hand-rolled RTSP/1.0)의 DIGEST/BASIC 인증 설정입니다.
+type AuthST struct {
+	Realm string       `json:"realm"`
+	Users []AuthUserST `json:"users"`
+}
+
+// AuthUserST는 RTSP 접속 계정 하나와 그 접근 범위를 정의합니다.
+type AuthUserST struct {
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	Streams    []string `json:"streams"` // 비어 있으면 모든 스트림 허용
+	ReadOnly   bool     `json:"read_only"`
+	AllowedIPs []string `json:"allowed_ips"` // CIDR 목록, 비어 있으면 모든 IP 허용
+}
+
 // APIST struct
 type APIST struct {
 	CCTVMasterURL string `json:"cctv_master_url"`
@@ -44,13 +81,35 @@ type CCTVResponse struct {
 
 // ServerST struct
 type ServerST struct {
-	HTTPPort      string   `json:"http_port"`
-	RTSPPort      string   `json:"rtsp_port"`
-	ICEServers    []string `json:"ice_servers"`
-	ICEUsername   string   `json:"ice_username"`
-	ICECredential string   `json:"ice_credential"`
-	WebRTCPortMin uint16   `json:"webrtc_port_min"`
-	WebRTCPortMax uint16   `json:"webrtc_port_max"`
+	HTTPPort        string   `json:"http_port"`
+	RTSPPort        string   `json:"rtsp_port"`
+	ICEServers      []string `json:"ice_servers"`
+	ICEUsername     string   `json:"ice_username"`
+	ICECredential   string   `json:"ice_credential"`
+	WebRTCPortMin   uint16   `json:"webrtc_port_min"`
+	WebRTCPortMax   uint16   `json:"webrtc_port_max"`
+	HLSEnable       bool     `json:"hls_enable"`
+	HLSSegmentCount int      `json:"hls_segment_count"`
+	HLSPartDuration float64  `json:"hls_part_duration"`
+	// NetworkInterfaces는 /stream/api/server/status의 네트워크 통계 수집 대상 인터페이스입니다.
+	// 비어 있으면 루프백을 제외한 전체 인터페이스를 사용합니다.
+	NetworkInterfaces []string `json:"network_interfaces"`
+	// AuthTokens는 Authorization: Bearer 헤더 또는 ?token=으로 허용되는 정적 토큰 목록입니다.
+	AuthTokens []string `json:"auth_tokens"`
+	// AuthHMACSecret이 설정되면 ?token=...&exp=...로 전달되는 단기 서명 URL 토큰을 검증합니다.
+	AuthHMACSecret string `json:"auth_hmac_secret"`
+	// CORSAllowOrigins는 Access-Control-Allow-Origin으로 반사될 허용 오리진 목록입니다.
+	// 비어 있으면 기존 동작과 호환되도록 "*"를 사용합니다.
+	CORSAllowOrigins []string `json:"cors_allow_origins"`
+	// RTPPortMin/RTPPortMax는 RTSPServer가 RTP-over-UDP 세션에 할당하는 서버측
+	// RTP/RTCP 포트 쌍의 범위입니다. 0이면 기본값(20000-20999)을 사용합니다.
+	RTPPortMin uint16 `json:"rtp_port_min"`
+	RTPPortMax uint16 `json:"rtp_port_max"`
+	// RTSPTLSPort가 설정되면 RTSPCertFile/RTSPKeyFile로 RTSPS(TLS로 감싼 RTSP)
+	// 리스너를 RTSPPort와 별도로 띄웁니다. 비어 있으면 RTSPS는 비활성화됩니다.
+	RTSPTLSPort  string `json:"rtsp_tls_port"`
+	RTSPCertFile string `json:"rtsp_cert_file"`
+	RTSPKeyFile  string `json:"rtsp_key_file"`
 }
 
 // StreamST struct
@@ -62,16 +121,52 @@ type StreamST struct {
 	Debug          bool   `json:"debug"`
 	RunLock        bool   `json:"-"`
 	Codecs         []av.CodecData
-	Cl             map[string]viewer
-	LastError      error     `json:"last_error"`
+	Ring           *RingBuffer `json:"-"`
+	LastError      error       `json:"last_error"`
 	LastUpdated    time.Time `json:"last_updated"`
 	ViewerCount    int       `json:"viewer_count"`
 	IsRunning      bool      `json:"is_running"`
 	ReconnectCount int       `json:"reconnect_count"`
+	// ACL은 역할("play", "publish", "admin")별로 접근을 허용할 토큰 목록입니다.
+	// 역할 키가 없으면 그 역할은 이 스트림에 한해 제한 없이 허용됩니다.
+	ACL map[string][]string `json:"acl,omitempty"`
+
+	// SourceProtocol은 RTSPWorkerLoop가 업스트림에 접속할 때 선호하는 전송입니다
+	// ("udp"/"tcp"/""=auto). rtspv2 클라이언트에 그대로 전달됩니다.
+	SourceProtocol string `json:"source_protocol"`
+
+	// RunOnInit는 스트림 등록 시 한 번 실행되는 셸 명령입니다(예: 카메라를 깨우는 스크립트).
+	RunOnInit string `json:"run_on_init"`
+	// RunOnDemand는 첫 구독자(RTSP PLAY/HLS 재생목록 요청 등)가 생길 때 실행되는 셸
+	// 명령입니다. RunOnDemandStartTimeout(초, 기본 10) 동안 트랙이 준비되길 기다린 뒤
+	// 구독을 진행하고, 마지막 구독 이후 RunOnDemandCloseAfter(초, 기본 10)가 지나면
+	// 프로세스를 종료합니다. URL이 "publisher"인 경로에서 외부 퍼블리셔(ffmpeg 등)를
+	// 기동할 때 주로 씁니다.
+	RunOnDemand             string `json:"run_on_demand"`
+	RunOnDemandStartTimeout int    `json:"run_on_demand_start_timeout"`
+	RunOnDemandCloseAfter   int    `json:"run_on_demand_close_after"`
+
+	// PublishUser/PublishPass, ReadUser/ReadPass가 설정되면 이 스트림에 한해 전역
+	// Config.Auth.Users보다 우선 적용되는 RTSP Digest/Basic 자격 증명이 됩니다.
+	// ReadIPs는 읽기(PLAY/HLS) 클라이언트에 적용되는 CIDR 허용 목록입니다.
+	PublishUser string   `json:"publish_user"`
+	PublishPass string   `json:"publish_pass"`
+	ReadUser    string   `json:"read_user"`
+	ReadPass    string   `json:"read_pass"`
+	ReadIPs     []string `json:"read_ips"`
+
+	// SubStreams는 이 스트림의 보조(저해상도) RTSP 소스들입니다. 키는 서브 스트림
+	// 이름(예: "sub")이며, 각각 registerSubStream에 의해 "이름/서브이름" 합성 UUID로
+	// Config.Streams에 독립적인 항목으로 등록되어 자신만의 워커/워치독/재연결
+	// 카운터를 갖습니다(메인 스트림이 멈추거나 끊겨도 서브 스트림에 영향을 주지 않음).
+	SubStreams map[string]SubStreamConfig `json:"sub_streams,omitempty"`
 }
 
-type viewer struct {
-	c chan av.Packet
+// SubStreamConfig는 StreamST.SubStreams의 항목 하나를 설정합니다.
+type SubStreamConfig struct {
+	URL          string `json:"url"`
+	DisableAudio bool   `json:"disable_audio"`
+	Debug        bool   `json:"debug"`
 }
 
 func loadConfig() *ConfigST {
@@ -80,7 +175,7 @@ func loadConfig() *ConfigST {
 	if err == nil {
 		err = json.Unmarshal(data, &tmp)
 		if err != nil {
-			log.Fatalln(err)
+			Fatalf("%v", err)
 		}
 
 		// REST API에서 streams 정보 가져오기 시도
@@ -88,7 +183,7 @@ func loadConfig() *ConfigST {
 		if err == nil && len(streams) > 0 {
 			tmp.Streams = streams
 		} else {
-			log.Printf("Failed to load streams from API: %v. Using config file streams.", err)
+			Errorf("Failed to load streams from API: %v. Using config file streams.", err)
 		}
 
 		for i, v := range tmp.Streams {
@@ -102,7 +197,7 @@ func loadConfig() *ConfigST {
 			if !v.Debug {
 				v.Debug = tmp.StreamDefaults.Debug
 			}
-			v.Cl = make(map[string]viewer)
+			v.Ring = NewRingBuffer(streamRingBufferSize)
 			tmp.Streams[i] = v
 		}
 	} else {
@@ -180,12 +275,10 @@ func (element *ConfigST) RunUnlock(uuid string) {
 }
 
 func (element *ConfigST) HasViewer(uuid string) bool {
-	element.mutex.Lock()
-	defer element.mutex.Unlock()
-	if tmp, ok := element.Streams[uuid]; ok && len(tmp.Cl) > 0 {
-		return true
-	}
-	return false
+	element.mutex.RLock()
+	tmp, ok := element.Streams[uuid]
+	element.mutex.RUnlock()
+	return ok && tmp.Ring != nil && tmp.Ring.ReaderCount() > 0
 }
 
 func (element *ConfigST) GetICEServers() []string {
@@ -218,14 +311,21 @@ func (element *ConfigST) GetWebRTCPortMax() uint16 {
 	return element.Server.WebRTCPortMax
 }
 
+// cast는 RTSP 소스 루프에서 호출되는 패킷 팬아웃 경로입니다. RingBuffer.Write는
+// 느린 reader를 기다리지 않으므로, 여기서 잡는 RLock은 스트림 맵 조회에만 쓰이고
+// 패킷 기록/배포 동안 유지되지 않습니다.
 func (element *ConfigST) cast(uuid string, pck av.Packet) {
-	element.mutex.Lock()
-	defer element.mutex.Unlock()
-	for _, v := range element.Streams[uuid].Cl {
-		if len(v.c) < cap(v.c) {
-			v.c <- pck
-		}
+	element.mutex.RLock()
+	stream := element.Streams[uuid]
+	element.mutex.RUnlock()
+
+	if stream.Ring == nil {
+		return
 	}
+	stream.Ring.Write(pck)
+
+	isVideo := int(pck.Idx) < len(stream.Codecs) && stream.Codecs[pck.Idx].Type().IsVideo()
+	recordPacketMetrics(uuid, pck, isVideo)
 }
 
 func (element *ConfigST) ext(suuid string) bool {
@@ -258,10 +358,10 @@ func (element *ConfigST) coGe(suuid string) []av.CodecData {
 					codecVideo := codec.(h264parser.CodecData)
 					if codecVideo.SPS() != nil && codecVideo.PPS() != nil && len(codecVideo.SPS()) > 0 && len(codecVideo.PPS()) > 0 {
 						//ok
-						//log.Println("Ok Video Ready to play")
+						//Info("Ok Video Ready to play")
 					} else {
 						//video codec not ok
-						log.Println("Bad Video Codec SPS or PPS Wait")
+						Debug("Bad Video Codec SPS or PPS Wait")
 						time.Sleep(50 * time.Millisecond)
 						continue
 					}
@@ -274,13 +374,21 @@ func (element *ConfigST) coGe(suuid string) []av.CodecData {
 	return nil
 }
 
-func (element *ConfigST) clAd(suuid string) (string, chan av.Packet) {
-	element.mutex.Lock()
-	defer element.mutex.Unlock()
-	cuuid := pseudoUUID()
-	ch := make(chan av.Packet, 100)
-	element.Streams[suuid].Cl[cuuid] = viewer{c: ch}
-	return cuuid, ch
+// clAd는 suuid 스트림의 RingBuffer에 새 reader를 등록합니다. 반환된 *RingReader로
+// Read를 반복 호출해 패킷을 소비하고, 끝나면 clDe로 반납해야 합니다. protocol은
+// "rtsp"/"hls"/"ll-hls"/"webrtc"/"whep"/"relay"처럼 시청자 수 메트릭을 쪼개는 레이블입니다.
+func (element *ConfigST) clAd(suuid string, protocol string) *RingReader {
+	element.EnsureSourceRunning(suuid)
+
+	element.mutex.RLock()
+	stream := element.Streams[suuid]
+	element.mutex.RUnlock()
+	if stream.Ring == nil {
+		return nil
+	}
+	reader := stream.Ring.NewReader()
+	recordViewerConnect(suuid, protocol)
+	return reader
 }
 
 func (element *ConfigST) list() (string, []string) {
@@ -296,10 +404,12 @@ func (element *ConfigST) list() (string, []string) {
 	}
 	return fist, res
 }
-func (element *ConfigST) clDe(suuid, cuuid string) {
-	element.mutex.Lock()
-	defer element.mutex.Unlock()
-	delete(element.Streams[suuid].Cl, cuuid)
+// clDe는 clAd로 받은 reader를 반납하고, 같은 protocol 레이블로 시청자 수 메트릭을 줄입니다.
+func (element *ConfigST) clDe(suuid string, protocol string, reader *RingReader) {
+	if reader != nil {
+		reader.Close()
+		recordViewerDisconnect(suuid, protocol)
+	}
 }
 
 func pseudoUUID() (uuid string) {
@@ -339,7 +449,6 @@ func (element *ConfigST) HandleStreamError(uuid string, err error) {
 
 func (element *ConfigST) UpdateViewerCount(uuid string, count int) {
 	element.mutex.Lock()
-	defer element.mutex.Unlock()
 	if stream, ok := element.Streams[uuid]; ok {
 		// StreamST 직접 업데이트
 		stream.ViewerCount = count
@@ -349,4 +458,7 @@ func (element *ConfigST) UpdateViewerCount(uuid string, count int) {
 		}
 		element.Streams[uuid] = stream
 	}
+	status := element.Streams[uuid]
+	element.mutex.Unlock()
+	recordStreamState(uuid, status.Status)
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthStaleAfter는 on-demand가 아닌 스트림이 이 기간 동안 갱신되지 않으면
+// /healthz가 비정상(503)으로 간주하는 임계값입니다.
+const healthStaleAfter = 30 * time.Second
+
+// StreamHealthInfo는 /streams 응답에 담기는, 운영 관점의 스트림 카운터 묶음입니다.
+type StreamHealthInfo struct {
+	UUID           string    `json:"uuid"`
+	URL            string    `json:"url"`
+	Status         bool      `json:"status"`
+	OnDemand       bool      `json:"on_demand"`
+	IsRunning      bool      `json:"is_running"`
+	ViewerCount    int       `json:"viewer_count"`
+	ReconnectCount int       `json:"reconnect_count"`
+	PacketsTotal   uint64    `json:"packets_total"`
+	BytesTotal     uint64    `json:"bytes_total"`
+	DropsTotal     uint64    `json:"drops_total"`
+	LastFrameAt    time.Time `json:"last_frame_at,omitempty"`
+	LastUpdated    time.Time `json:"last_updated"`
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+// registerHealthRoutes는 /healthz, /streams 엔드포인트를 등록합니다.
+func registerHealthRoutes(router *gin.Engine) {
+	router.GET("/healthz", handleHealthz)
+	router.GET("/streams", handleStreamsList)
+}
+
+// handleHealthz는 on-demand가 아니면서 IsRunning인 스트림 중 LastUpdated가
+// healthStaleAfter보다 오래된 것이 있으면 503을 반환합니다.
+func handleHealthz(c *gin.Context) {
+	Config.mutex.RLock()
+	defer Config.mutex.RUnlock()
+
+	for uuid, stream := range Config.Streams {
+		if stream.OnDemand || !stream.IsRunning {
+			continue
+		}
+		if age := time.Since(stream.LastUpdated); age > healthStaleAfter {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status": "unhealthy",
+				"reason": fmt.Sprintf("stream %s stale for %s", uuid, age.Round(time.Second)),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleStreamsList는 모든 스트림을 카운터(패킷/바이트/드롭/마지막 프레임 시각)와
+// 함께 나열합니다. /stream/api/status와 달리 운영 대시보드/장애 탐지용 필드에 집중합니다.
+func handleStreamsList(c *gin.Context) {
+	Config.mutex.RLock()
+	streams := make([]StreamHealthInfo, 0, len(Config.Streams))
+	for uuid, stream := range Config.Streams {
+		info := StreamHealthInfo{
+			UUID:           uuid,
+			URL:            stream.URL,
+			Status:         stream.Status,
+			OnDemand:       stream.OnDemand,
+			IsRunning:      stream.IsRunning,
+			ViewerCount:    stream.ViewerCount,
+			ReconnectCount: stream.ReconnectCount,
+			LastUpdated:    stream.LastUpdated,
+		}
+		if stream.LastError != nil {
+			info.LastError = stream.LastError.Error()
+		}
+		if stream.Ring != nil {
+			info.PacketsTotal = stream.Ring.PacketCount()
+			info.BytesTotal = stream.Ring.ByteCount()
+			info.DropsTotal = stream.Ring.Drops()
+			info.LastFrameAt = stream.Ring.LastFrameTime()
+		}
+		streams = append(streams, info)
+	}
+	Config.mutex.RUnlock()
+
+	c.JSON(http.StatusOK, gin.H{"streams": streams, "total": len(streams)})
+}
@@ -0,0 +1,748 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deepch/vdk/format/mp4f"
+	"github.com/deepch/vdk/format/mpegts"
+	"github.com/gin-gonic/gin"
+)
+
+// HLSPart는 LL-HLS의 #EXT-X-PART 한 개에 대응하는 부분 세그먼트입니다.
+type HLSPart struct {
+	Independent bool
+	Duration    float64
+	Data        []byte
+}
+
+// HLSSegment는 하나의 fMP4 세그먼트(.m4s)와 그 구성 파트들을 담습니다.
+type HLSSegment struct {
+	Seq      int
+	Duration float64
+	Data     []byte
+	Parts    []HLSPart
+	complete bool
+}
+
+// hlsStream은 UUID 하나당 유지되는 fMP4 세그멘터 상태입니다.
+type hlsStream struct {
+	mu           sync.Mutex
+	uuid         string
+	init         []byte
+	muxer        *mp4f.Muxer
+	segments     []*HLSSegment
+	nextSeq      int
+	lastAccessed time.Time
+	waiters      []chan bool
+	stopCh       chan bool
+}
+
+var (
+	hlsStreams   = make(map[string]*hlsStream)
+	hlsStreamsMu sync.Mutex
+)
+
+// HLS 세그먼트/파트 길이와 재생목록 창 크기. 고정 상수이며, Config.Server의
+// HLSSegmentCount/HLSPartDuration 값은 읽지 않습니다.
+const (
+	hlsDefaultSegmentDuration = 2 * time.Second
+	hlsDefaultPartDuration    = 250 * time.Millisecond
+	hlsPlaylistWindow         = 6
+	hlsIdleTimeout            = 60 * time.Second
+)
+
+func getOrStartHLSStream(uuid string) (*hlsStream, error) {
+	hlsStreamsMu.Lock()
+	defer hlsStreamsMu.Unlock()
+
+	if hs, ok := hlsStreams[uuid]; ok {
+		hs.mu.Lock()
+		hs.lastAccessed = time.Now()
+		hs.mu.Unlock()
+		return hs, nil
+	}
+
+	Config.RunIFNotRun(uuid)
+	codecs := Config.coGe(uuid)
+	if codecs == nil {
+		return nil, fmt.Errorf("codec data not ready for stream %s", uuid)
+	}
+
+	muxer := mp4f.NewMuxer(nil)
+	if err := muxer.WriteHeader(codecs); err != nil {
+		return nil, fmt.Errorf("mp4f WriteHeader: %v", err)
+	}
+	_, init := muxer.GetInit()
+
+	hs := &hlsStream{
+		uuid:         uuid,
+		init:         init,
+		muxer:        muxer,
+		lastAccessed: time.Now(),
+		stopCh:       make(chan bool, 1),
+	}
+	hlsStreams[uuid] = hs
+	go hs.run()
+	go hs.watchIdle()
+	return hs, nil
+}
+
+// run은 구독 채널에서 패킷을 읽어 키프레임 기준으로 세그먼트를 쌓고,
+// 첫 키프레임이 올 때까지는 아무 세그먼트도 만들지 않습니다 (WebRTC 경로와 동일하게
+// 최초 요청이 키프레임을 기다리도록 합니다).
+func (hs *hlsStream) run() {
+	reader := Config.clAd(hs.uuid, "ll-hls")
+	defer Config.clDe(hs.uuid, "ll-hls", reader)
+
+	var current *HLSSegment
+	var segStart time.Time
+	var partStart time.Time
+	var haveKeyframe bool
+
+	for {
+		select {
+		case <-hs.stopCh:
+			return
+		default:
+		}
+
+		pck, ok := reader.Read(2 * time.Second)
+		if !ok {
+			continue
+		}
+
+		ready, buf, err := hs.muxer.WritePacket(pck, false)
+		if err != nil {
+			Error("HLS WritePacket", hs.uuid, err)
+			continue
+		}
+		if !ready || len(buf) == 0 {
+			continue
+		}
+
+		if pck.IsKeyFrame {
+			if current != nil {
+				hs.finishSegment(current)
+			}
+			haveKeyframe = true
+			current = &HLSSegment{Seq: hs.nextSeq}
+			hs.nextSeq++
+			segStart = time.Now()
+			partStart = segStart
+		}
+		if !haveKeyframe || current == nil {
+			continue
+		}
+
+		current.Data = append(current.Data, buf...)
+		current.Parts = append(current.Parts, HLSPart{
+			Independent: pck.IsKeyFrame && len(current.Parts) == 0,
+			Duration:    time.Since(partStart).Seconds(),
+			Data:        buf,
+		})
+		partStart = time.Now()
+		hs.notifyWaiters()
+
+		if time.Since(segStart) >= hlsDefaultSegmentDuration {
+			// 다음 키프레임까지 현재 세그먼트를 유지하고 길이만 기록합니다.
+			current.Duration = time.Since(segStart).Seconds()
+		}
+	}
+}
+
+func (hs *hlsStream) finishSegment(seg *HLSSegment) {
+	hs.mu.Lock()
+	seg.complete = true
+	hs.segments = append(hs.segments, seg)
+	if len(hs.segments) > hlsPlaylistWindow {
+		hs.segments = hs.segments[len(hs.segments)-hlsPlaylistWindow:]
+	}
+	hs.mu.Unlock()
+	hs.notifyWaiters()
+}
+
+func (hs *hlsStream) notifyWaiters() {
+	hs.mu.Lock()
+	waiters := hs.waiters
+	hs.waiters = nil
+	hs.mu.Unlock()
+	for _, w := range waiters {
+		close(w)
+	}
+}
+
+// watchIdle은 약 60초간 플레이리스트 폴링이 없으면 세그멘터를 정리합니다.
+func (hs *hlsStream) watchIdle() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-hs.stopCh:
+			return
+		case <-ticker.C:
+			hs.mu.Lock()
+			idle := time.Since(hs.lastAccessed) > hlsIdleTimeout
+			hs.mu.Unlock()
+			if idle {
+				hlsStreamsMu.Lock()
+				delete(hlsStreams, hs.uuid)
+				hlsStreamsMu.Unlock()
+				hs.stopCh <- true
+				return
+			}
+		}
+	}
+}
+
+// waitForSegment는 LL-HLS 블로킹 재생목록 요청(_HLS_msn)을 위해 해당 시퀀스가
+// 생길 때까지 대기합니다.
+func (hs *hlsStream) waitForSegment(seq int, timeout time.Duration) {
+	hs.mu.Lock()
+	for _, s := range hs.segments {
+		if s.Seq >= seq {
+			hs.mu.Unlock()
+			return
+		}
+	}
+	wait := make(chan bool)
+	hs.waiters = append(hs.waiters, wait)
+	hs.mu.Unlock()
+
+	select {
+	case <-wait:
+	case <-time.After(timeout):
+	}
+}
+
+// checkHLSReadAuth는 StreamST.ReadUser/ReadPass/ReadIPs를 강제합니다. rtsp_api.go의
+// HandleRTSPStreamInfo와 동일한 authenticateRTSPRequest/streamAllowed 401/403 규약을
+// gin.Context에 대해 적용합니다. 실패 시 응답을 쓰고 false를 반환합니다.
+func checkHLSReadAuth(c *gin.Context, uuid string) bool {
+	user, ok := authenticateRTSPRequest(c.GetHeader("Authorization"), "GET", c.Request.URL.Path, c.Request.RemoteAddr, uuid, false)
+	if !ok {
+		c.Header("WWW-Authenticate", rtspWWWAuthenticateHeader())
+		c.Status(http.StatusUnauthorized)
+		return false
+	}
+	if user != nil && !streamAllowed(user, uuid) {
+		c.Status(http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// HTTPAPIServerHLSPlaylist는 /stream/hls/:uuid/index.m3u8 를 서빙합니다.
+// 첫 요청은 on-demand로 스트림을 기동하고 최초 키프레임 세그먼트가 준비될 때까지 블록합니다.
+func HTTPAPIServerHLSPlaylist(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !Config.ext(uuid) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if !checkHLSReadAuth(c, uuid) {
+		return
+	}
+	hs, err := getOrStartHLSStream(uuid)
+	if err != nil {
+		c.String(http.StatusServiceUnavailable, "%v", err)
+		return
+	}
+	hs.mu.Lock()
+	hs.lastAccessed = time.Now()
+	hs.mu.Unlock()
+
+	if msn := c.Query("_HLS_msn"); msn != "" {
+		if n, err := strconv.Atoi(msn); err == nil {
+			hs.waitForSegment(n, 5*time.Second)
+		}
+	}
+
+	hs.mu.Lock()
+	empty := len(hs.segments) == 0
+	hs.mu.Unlock()
+	if empty {
+		hs.waitForSegment(0, 5*time.Second)
+	}
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:9\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(hlsDefaultSegmentDuration.Seconds())+1)
+	fmt.Fprintf(&b, "#EXT-X-PART-INF:PART-TARGET=%.3f\n", hlsDefaultPartDuration.Seconds())
+	if len(hs.segments) > 0 {
+		fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", hs.segments[0].Seq)
+	}
+	fmt.Fprintf(&b, "#EXT-X-MAP:URI=\"init.mp4\"\n")
+	for _, seg := range hs.segments {
+		for i, part := range seg.Parts {
+			fmt.Fprintf(&b, "#EXT-X-PART:DURATION=%.3f,URI=\"segment%d_part%d.m4s\"%s\n",
+				part.Duration, seg.Seq, i, ifIndependent(part.Independent))
+		}
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n#EXT-X-PROGRAM-DATE-TIME:%s\nsegment%d.m4s\n",
+			seg.Duration, time.Now().Format(time.RFC3339), seg.Seq)
+	}
+	fmt.Fprintf(&b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"segment%d_part%d.m4s\"\n", hs.nextSeq, 0)
+
+	c.Header("Content-Type", "application/vnd.apple.mpegurl")
+	c.String(http.StatusOK, "%s", b.String())
+}
+
+func ifIndependent(independent bool) string {
+	if independent {
+		return ",INDEPENDENT=YES"
+	}
+	return ""
+}
+
+// HTTPAPIServerHLSInit는 fMP4 초기화 세그먼트(moov/ftyp)를 서빙합니다.
+func HTTPAPIServerHLSInit(c *gin.Context) {
+	uuid := c.Param("uuid")
+	hlsStreamsMu.Lock()
+	hs, ok := hlsStreams[uuid]
+	hlsStreamsMu.Unlock()
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Content-Type", "video/mp4")
+	c.Data(http.StatusOK, "video/mp4", hs.init)
+}
+
+// HTTPAPIServerHLSSegment는 segmentN.m4s 및 LL-HLS segmentN_partM.m4s 요청을 서빙합니다.
+func HTTPAPIServerHLSSegment(c *gin.Context) {
+	uuid := c.Param("uuid")
+	name := c.Param("segment")
+
+	hlsStreamsMu.Lock()
+	hs, ok := hlsStreams[uuid]
+	hlsStreamsMu.Unlock()
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	hs.mu.Lock()
+	hs.lastAccessed = time.Now()
+	hs.mu.Unlock()
+
+	name = strings.TrimPrefix(name, "/")
+	name = strings.TrimSuffix(name, ".m4s")
+	name = strings.TrimPrefix(name, "segment")
+
+	var seq, part int
+	var err error
+	if strings.Contains(name, "_part") {
+		pieces := strings.SplitN(name, "_part", 2)
+		seq, err = strconv.Atoi(pieces[0])
+		if err == nil {
+			part, err = strconv.Atoi(pieces[1])
+		}
+	} else {
+		seq, err = strconv.Atoi(name)
+		part = -1
+	}
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	hs.waitForSegment(seq, 5*time.Second)
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	for _, seg := range hs.segments {
+		if seg.Seq != seq {
+			continue
+		}
+		if part >= 0 {
+			if part >= len(seg.Parts) {
+				c.Status(http.StatusNotFound)
+				return
+			}
+			c.Data(http.StatusOK, "video/mp4", seg.Parts[part].Data)
+			return
+		}
+		c.Data(http.StatusOK, "video/mp4", seg.Data)
+		return
+	}
+	c.Status(http.StatusNotFound)
+}
+
+// registerHLSRoutes는 HLS/LL-HLS 엔드포인트를 등록합니다.
+func registerHLSRoutes(router *gin.Engine) {
+	router.GET("/stream/hls/:uuid/index.m3u8", HTTPAPIServerHLSPlaylist)
+	router.GET("/stream/hls/:uuid/init.mp4", HTTPAPIServerHLSInit)
+	router.GET("/stream/hls/:uuid/:segment", HTTPAPIServerHLSSegment)
+
+	registerClassicHLSRoutes(router)
+	registerSubStreamHLSRoutes(router)
+}
+
+// withSubStreamUUID는 "/stream/:name/:sub/..." 라우트의 name/sub 파라미터를
+// subStreamUUID(name, sub) 합성 uuid로 묶어 "uuid" 파라미터로 주입한 뒤, 기존의
+// (uuid 하나만 알면 되는) HLS 핸들러에 그대로 위임합니다. 덕분에 서브 스트림은
+// LL-HLS/classic HLS 핸들러를 한 글자도 고치지 않고 그대로 재사용합니다.
+func withSubStreamUUID(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uuid := subStreamUUID(c.Param("name"), c.Param("sub"))
+		c.Params = append(c.Params, gin.Param{Key: "uuid", Value: uuid})
+		handler(c)
+	}
+}
+
+// registerSubStreamHLSRoutes는 요청 본문에서 요구한 "/stream/{name}/{sub}" HLS
+// 엔드포인트를 등록합니다. 기존 LL-HLS(fMP4)와 classic HLS(MPEG-TS) 핸들러를
+// 합성 uuid로 그대로 재사용하므로 클라이언트는 메인 스트림과 동일한 방식으로
+// 서브 스트림을 재생할 수 있습니다.
+func registerSubStreamHLSRoutes(router *gin.Engine) {
+	router.GET("/stream/:name/:sub/index.m3u8", withSubStreamUUID(HTTPAPIServerHLSPlaylist))
+	router.GET("/stream/:name/:sub/init.mp4", withSubStreamUUID(HTTPAPIServerHLSInit))
+	router.GET("/stream/:name/:sub/:segment", withSubStreamUUID(HTTPAPIServerHLSSegment))
+}
+
+// --- Classic (MPEG-TS) HLS ---
+//
+// 위쪽의 hlsStream은 LL-HLS용 fMP4 세그멘터입니다. 아래의 classicHLSStream은
+// LL-HLS/fMP4를 지원하지 않는 구형 플레이어(예: 구형 Safari, 일부 셋톱박스)를 위한
+// 전통적인 MPEG-TS 기반 HLS를 "/hls/{uuid}/..." 경로로 제공합니다.
+
+// classicHLSSegment는 하나의 .ts 세그먼트입니다.
+type classicHLSSegment struct {
+	Seq      int
+	Duration float64
+	Data     []byte
+}
+
+// classicHLSStream은 UUID 하나당 유지되는 MPEG-TS 세그멘터 상태입니다.
+// mpegts.Muxer는 io.Writer 한 개에만 리먹싱하므로, WritePacket이 내놓는 바이트를
+// 세그먼트 단위로 떼어내기 위해 muxer가 쓰는 대상을 buf에 고정해두고 패킷을 쓸 때마다
+// buf를 비워 읽어냅니다.
+type classicHLSStream struct {
+	mu           sync.Mutex
+	uuid         string
+	muxer        *mpegts.Muxer
+	buf          *bytes.Buffer
+	header       []byte
+	segments     []*classicHLSSegment
+	nextSeq      int
+	lastAccessed time.Time
+	waiters      []chan bool
+	stopCh       chan bool
+}
+
+var (
+	classicHLSStreams   = make(map[string]*classicHLSStream)
+	classicHLSStreamsMu sync.Mutex
+)
+
+const (
+	classicHLSMinAccessUnits  = 100
+	classicHLSMinSegmentPTS   = 1 * time.Second
+	classicHLSMaxSegmentPTS   = 6 * time.Second
+	classicHLSPlaylistWindow  = 5
+	classicHLSStartupPTSDelay = 2 * time.Second
+)
+
+func getOrStartClassicHLSStream(uuid string) (*classicHLSStream, error) {
+	classicHLSStreamsMu.Lock()
+	defer classicHLSStreamsMu.Unlock()
+
+	if cs, ok := classicHLSStreams[uuid]; ok {
+		cs.mu.Lock()
+		cs.lastAccessed = time.Now()
+		cs.mu.Unlock()
+		return cs, nil
+	}
+
+	Config.RunIFNotRun(uuid)
+	codecs := Config.coGe(uuid)
+	if codecs == nil {
+		return nil, fmt.Errorf("codec data not ready for stream %s", uuid)
+	}
+
+	buf := &bytes.Buffer{}
+	muxer := mpegts.NewMuxer(buf)
+	if err := muxer.WriteHeader(codecs); err != nil {
+		return nil, fmt.Errorf("mpegts WriteHeader: %v", err)
+	}
+	header := append([]byte(nil), buf.Bytes()...)
+	buf.Reset()
+
+	cs := &classicHLSStream{
+		uuid:         uuid,
+		muxer:        muxer,
+		buf:          buf,
+		header:       header,
+		lastAccessed: time.Now(),
+		stopCh:       make(chan bool, 1),
+	}
+	classicHLSStreams[uuid] = cs
+	go cs.run()
+	go cs.watchIdle()
+	return cs, nil
+}
+
+// run은 구독 채널에서 패킷을 읽어 키프레임 기준으로 .ts 세그먼트를 쌓습니다.
+// 현재 세그먼트가 최소 ~100 액세스 유닛 또는 ~1초의 PTS를 채운 뒤 다음 키프레임이
+// 오면 세그먼트를 닫고, 최대 ~6초가 지나면 다음 키프레임을 기다리지 않고 강제로 닫습니다.
+func (cs *classicHLSStream) run() {
+	reader := Config.clAd(cs.uuid, "hls")
+	defer Config.clDe(cs.uuid, "hls", reader)
+
+	var segStart time.Time
+	var segStartPTS time.Duration
+	var current *classicHLSSegment
+	var accessUnits int
+	var haveKeyframe bool
+
+	closeSegment := func() {
+		if current == nil {
+			return
+		}
+		current.Duration = time.Since(segStart).Seconds()
+		cs.finishSegment(current)
+		current = nil
+		accessUnits = 0
+	}
+
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		default:
+		}
+
+		pck, ok := reader.Read(2 * time.Second)
+		if !ok {
+			continue
+		}
+
+		// 시작 시 음수/역전된 타임스탬프를 피하기 위한 PTS 오프셋.
+		pck.Time += classicHLSStartupPTSDelay
+
+		if pck.IsKeyFrame {
+			segmentFull := current != nil &&
+				(accessUnits >= classicHLSMinAccessUnits || pck.Time-segStartPTS >= classicHLSMinSegmentPTS)
+			if segmentFull {
+				closeSegment()
+			}
+			if current == nil {
+				haveKeyframe = true
+				current = &classicHLSSegment{Seq: cs.nextSeq}
+				cs.nextSeq++
+				segStart = time.Now()
+				segStartPTS = pck.Time
+				if cs.header != nil {
+					current.Data = append(current.Data, cs.header...)
+					cs.header = nil
+				}
+			}
+		}
+		if !haveKeyframe || current == nil {
+			continue
+		}
+
+		if err := cs.muxer.WritePacket(pck); err != nil {
+			Error("Classic HLS WritePacket", cs.uuid, err)
+			continue
+		}
+		current.Data = append(current.Data, cs.buf.Bytes()...)
+		cs.buf.Reset()
+		accessUnits++
+
+		if pck.Time-segStartPTS >= classicHLSMaxSegmentPTS {
+			closeSegment()
+		}
+	}
+}
+
+func (cs *classicHLSStream) finishSegment(seg *classicHLSSegment) {
+	cs.mu.Lock()
+	cs.segments = append(cs.segments, seg)
+	if len(cs.segments) > classicHLSPlaylistWindow {
+		cs.segments = cs.segments[len(cs.segments)-classicHLSPlaylistWindow:]
+	}
+	cs.mu.Unlock()
+	cs.notifyWaiters()
+}
+
+func (cs *classicHLSStream) notifyWaiters() {
+	cs.mu.Lock()
+	waiters := cs.waiters
+	cs.waiters = nil
+	cs.mu.Unlock()
+	for _, w := range waiters {
+		close(w)
+	}
+}
+
+func (cs *classicHLSStream) waitForFirstSegment(timeout time.Duration) {
+	cs.mu.Lock()
+	if len(cs.segments) > 0 {
+		cs.mu.Unlock()
+		return
+	}
+	wait := make(chan bool)
+	cs.waiters = append(cs.waiters, wait)
+	cs.mu.Unlock()
+
+	select {
+	case <-wait:
+	case <-time.After(timeout):
+	}
+}
+
+// watchIdle은 약 60초간 폴링이 없으면 세그멘터를 정리합니다.
+func (cs *classicHLSStream) watchIdle() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ticker.C:
+			cs.mu.Lock()
+			idle := time.Since(cs.lastAccessed) > hlsIdleTimeout
+			cs.mu.Unlock()
+			if idle {
+				classicHLSStreamsMu.Lock()
+				delete(classicHLSStreams, cs.uuid)
+				classicHLSStreamsMu.Unlock()
+				cs.stopCh <- true
+				return
+			}
+		}
+	}
+}
+
+// HTTPAPIServerClassicHLSPlaylist는 /hls/:uuid/index.m3u8 를 서빙합니다.
+func HTTPAPIServerClassicHLSPlaylist(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !Config.ext(uuid) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if !checkHLSReadAuth(c, uuid) {
+		return
+	}
+	cs, err := getOrStartClassicHLSStream(uuid)
+	if err != nil {
+		c.String(http.StatusServiceUnavailable, "%v", err)
+		return
+	}
+	cs.mu.Lock()
+	cs.lastAccessed = time.Now()
+	cs.mu.Unlock()
+
+	cs.waitForFirstSegment(5 * time.Second)
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	targetDuration := int(classicHLSMaxSegmentPTS.Seconds())
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", targetDuration)
+	if len(cs.segments) > 0 {
+		fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", cs.segments[0].Seq)
+	}
+	for _, seg := range cs.segments {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\nsegment%d.ts\n", seg.Duration, seg.Seq)
+	}
+
+	c.Header("Content-Type", "application/vnd.apple.mpegurl")
+	c.String(http.StatusOK, "%s", b.String())
+}
+
+// HTTPAPIServerClassicHLSSegment는 /hls/:uuid/segmentN.ts 를 서빙합니다.
+func HTTPAPIServerClassicHLSSegment(c *gin.Context) {
+	uuid := c.Param("uuid")
+	name := c.Param("segment")
+
+	classicHLSStreamsMu.Lock()
+	cs, ok := classicHLSStreams[uuid]
+	classicHLSStreamsMu.Unlock()
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	cs.mu.Lock()
+	cs.lastAccessed = time.Now()
+	cs.mu.Unlock()
+
+	name = strings.TrimPrefix(name, "/")
+	name = strings.TrimSuffix(name, ".ts")
+	name = strings.TrimPrefix(name, "segment")
+	seq, err := strconv.Atoi(name)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for _, seg := range cs.segments {
+		if seg.Seq == seq {
+			c.Data(http.StatusOK, "video/mp2t", seg.Data)
+			return
+		}
+	}
+	c.Status(http.StatusNotFound)
+}
+
+// classicHLSViewerPage는 hls.js(CDN)만으로 재생하는 최소한의 뷰어 페이지입니다.
+// 브라우저가 hls.js를 지원하지 않고 네이티브 HLS를 지원하면(Safari) video 태그에
+// .m3u8을 직접 연결하는 경로로 대체합니다.
+const classicHLSViewerPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%[1]s</title>
+<script src="https://cdn.jsdelivr.net/npm/hls.js@1/dist/hls.min.js"></script>
+<style>body{margin:0;background:#000}video{width:100%%;height:100vh}</style>
+</head>
+<body>
+<video id="video" controls autoplay muted playsinline></video>
+<script>
+var video = document.getElementById('video');
+var src = 'index.m3u8';
+if (Hls.isSupported()) {
+  var hls = new Hls();
+  hls.loadSource(src);
+  hls.attachMedia(video);
+} else if (video.canPlayType('application/vnd.apple.mpegurl')) {
+  video.src = src;
+}
+</script>
+</body>
+</html>
+`
+
+// HTTPAPIServerClassicHLSViewerPage는 /hls/:uuid/ 를 서빙하는, 설정이 필요 없는
+// hls.js 기반 뷰어입니다.
+func HTTPAPIServerClassicHLSViewerPage(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !Config.ext(uuid) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if !checkHLSReadAuth(c, uuid) {
+		return
+	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.String(http.StatusOK, classicHLSViewerPage, uuid)
+}
+
+// registerClassicHLSRoutes는 전통적인 MPEG-TS HLS 엔드포인트를 등록합니다.
+func registerClassicHLSRoutes(router *gin.Engine) {
+	router.GET("/hls/:uuid/", HTTPAPIServerClassicHLSViewerPage)
+	router.GET("/hls/:uuid/index.m3u8", HTTPAPIServerClassicHLSPlaylist)
+	router.GET("/hls/:uuid/:segment", HTTPAPIServerClassicHLSSegment)
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/syslog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel은 로그의 심각도를 나타냅니다. 낮을수록 더 상세합니다.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	default:
+		return "INFO"
+	}
+}
+
+// parseLogLevel은 Config.Log.Level 문자열을 LogLevel로 변환합니다. 알 수 없는 값이거나
+// 비어 있으면 LevelInfo로 취급합니다.
+func parseLogLevel(s string) LogLevel {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug
+	case "warn", "warning":
+		return LevelWarn
+	case "error":
+		return LevelError
+	default:
+		return LevelInfo
+	}
+}
+
+const (
+	defaultLogMaxSize    = 10 * 1024 * 1024 // 10MB
+	defaultLogMaxBackups = 30
+)
+
+// LogRotator는 mediamtx의 로그 회전기와 비슷하게, 활성 로그 파일 하나(<prefix>.log)에
+// 쓰다가 크기 또는 날짜 조건을 넘으면 타임스탬프가 붙은 파일로 rename하고, 그 자리에
+// 바로(별도 goroutine 없이) gzip으로 압축합니다. file/curSize/curDay 전체가 mu로
+// 보호되어 Write와 rotate가 서로 경합하지 않습니다.
+type LogRotator struct {
+	mu          sync.Mutex
+	dir         string
+	prefix      string
+	maxSize     int64
+	rotateDaily bool
+	maxBackups  int
+
+	file    *os.File
+	curSize int64
+	curDay  string
+}
+
+// NewLogRotator는 dir/prefix.log를 활성 로그 파일로 열고(없으면 생성) LogRotator를
+// 돌려줍니다. maxSize<=0이면 기본값(10MB)을, maxBackups==0이면 기본값(30)을 씁니다.
+// maxBackups<0이면 회전된 파일을 무제한 보관합니다.
+func NewLogRotator(dir, prefix string, maxSize int64, rotateDaily bool, maxBackups int) (*LogRotator, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("로그 디렉토리 생성 실패: %w", err)
+	}
+	if maxSize <= 0 {
+		maxSize = defaultLogMaxSize
+	}
+	if maxBackups == 0 {
+		maxBackups = defaultLogMaxBackups
+	}
+
+	r := &LogRotator{
+		dir:         dir,
+		prefix:      prefix,
+		maxSize:     maxSize,
+		rotateDaily: rotateDaily,
+		maxBackups:  maxBackups,
+		curDay:      time.Now().Format("2006-01-02"),
+	}
+
+	file, err := os.OpenFile(r.activePath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("로그 파일 열기 실패: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("로그 파일 정보 확인 실패: %w", err)
+	}
+	r.file = file
+	r.curSize = info.Size()
+	return r, nil
+}
+
+func (r *LogRotator) activePath() string {
+	return filepath.Join(r.dir, r.prefix+".log")
+}
+
+// Write는 io.Writer를 만족합니다. 회전이 필요하면 먼저 회전한 뒤 씁니다.
+func (r *LogRotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+	needRotate := r.curSize+int64(len(p)) > r.maxSize
+	if r.rotateDaily && today != r.curDay {
+		needRotate = true
+	}
+	if needRotate {
+		if err := r.rotateLocked(); err != nil {
+			return 0, err
+		}
+	}
+	r.curDay = today
+
+	n, err := r.file.Write(p)
+	r.curSize += int64(n)
+	return n, err
+}
+
+// rotateLocked는 호출자가 이미 mu를 들고 있다고 가정합니다. 현재 파일을 닫고 타임스탬프가
+// 붙은 이름으로 원자적으로 rename한 뒤, 그 자리에서(백그라운드 없이) gzip으로 압축하고
+// 새 활성 파일을 엽니다. 마지막으로 보존 개수를 넘는 오래된 압축 파일을 정리합니다.
+func (r *LogRotator) rotateLocked() error {
+	if r.file != nil {
+		r.file.Close()
+	}
+
+	activePath := r.activePath()
+	if info, err := os.Stat(activePath); err == nil && info.Size() > 0 {
+		backupPath := filepath.Join(r.dir, fmt.Sprintf("%s-%s.log", r.prefix, time.Now().Format("2006-01-02T15-04-05.000")))
+		if err := os.Rename(activePath, backupPath); err != nil {
+			return fmt.Errorf("로그 파일 회전(rename) 실패: %w", err)
+		}
+		if err := gzipAndRemove(backupPath); err != nil {
+			// 압축 실패는 로깅 자체를 막을 이유가 아니므로 원본 .log는 그대로 남겨둡니다.
+			fmt.Fprintf(os.Stderr, "로그 파일 압축 실패: %v\n", err)
+		}
+	}
+
+	file, err := os.OpenFile(activePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("회전된 로그 파일 열기 실패: %w", err)
+	}
+	r.file = file
+	r.curSize = 0
+
+	r.pruneOldBackups()
+	return nil
+}
+
+func gzipAndRemove(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gw, src); err != nil {
+		gw.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// pruneOldBackups는 r.maxBackups를 넘는 오래된(이름순 정렬 시 가장 앞선, 즉 가장 오래된)
+// 압축 로그 파일을 삭제합니다. maxBackups<0이면 아무것도 지우지 않습니다.
+func (r *LogRotator) pruneOldBackups() {
+	if r.maxBackups < 0 {
+		return
+	}
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return
+	}
+	var backups []string
+	prefix := r.prefix + "-"
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".log.gz") {
+			backups = append(backups, name)
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > r.maxBackups {
+		os.Remove(filepath.Join(r.dir, backups[0]))
+		backups = backups[1:]
+	}
+}
+
+// Close는 활성 로그 파일을 닫습니다. SIGINT/SIGTERM 처리기에서 호출되어 깔끔하게
+// 플러시합니다.
+func (r *LogRotator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// Logger는 mediamtx의 internal/logger를 본떠 만든 레벨 있는 로거입니다. 여러 목적지
+// (표준 출력, 회전 파일, syslog)에 동시에 쓸 수 있고, level 미만인 호출은 버립니다.
+type Logger struct {
+	level   LogLevel
+	out     io.Writer
+	rotator *LogRotator
+	syslog  *syslog.Writer
+}
+
+// NewLogger는 cfg에 따라 목적지를 구성합니다. cfg.Dir이 비어 있으면 파일 로깅은 건너뛰고
+// 표준 출력만 사용합니다.
+func NewLogger(cfg LogST) (*Logger, error) {
+	lg := &Logger{level: parseLogLevel(cfg.Level)}
+
+	var writers []io.Writer
+	if cfg.Stdout == nil || *cfg.Stdout {
+		writers = append(writers, os.Stdout)
+	}
+
+	if cfg.Dir != "" {
+		prefix := cfg.Prefix
+		if prefix == "" {
+			prefix = "rtsp-rtsp"
+		}
+		rotator, err := NewLogRotator(cfg.Dir, prefix, int64(cfg.MaxSizeMB)*1024*1024, cfg.RotateDaily, cfg.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		lg.rotator = rotator
+		writers = append(writers, rotator)
+	}
+
+	if cfg.Syslog {
+		w, err := syslog.New(syslog.LOG_INFO, "rtsp-rtsp")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "syslog 연결 실패, syslog 출력 없이 계속합니다: %v\n", err)
+		} else {
+			lg.syslog = w
+			writers = append(writers, w)
+		}
+	}
+
+	if len(writers) == 0 {
+		writers = append(writers, os.Stdout)
+	}
+	lg.out = io.MultiWriter(writers...)
+	return lg, nil
+}
+
+func (lg *Logger) logf(level LogLevel, format string, args ...interface{}) {
+	if lg == nil || level < lg.level {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	line := fmt.Sprintf("%s [%s] %s\n", time.Now().Format("2006/01/02 15:04:05"), level.String(), msg)
+	fmt.Fprint(lg.out, line)
+}
+
+func (lg *Logger) Debugf(format string, args ...interface{}) { lg.logf(LevelDebug, format, args...) }
+func (lg *Logger) Infof(format string, args ...interface{})  { lg.logf(LevelInfo, format, args...) }
+func (lg *Logger) Warnf(format string, args ...interface{})  { lg.logf(LevelWarn, format, args...) }
+func (lg *Logger) Errorf(format string, args ...interface{}) { lg.logf(LevelError, format, args...) }
+
+// Debug/Info/Warn/Error는 log.Println과 같이 인자를 공백으로 이어붙여 출력합니다.
+func (lg *Logger) Debug(args ...interface{}) { lg.logf(LevelDebug, "%s", fmt.Sprintln(args...)[:len(fmt.Sprintln(args...))-1]) }
+func (lg *Logger) Info(args ...interface{})  { lg.logf(LevelInfo, "%s", fmt.Sprintln(args...)[:len(fmt.Sprintln(args...))-1]) }
+func (lg *Logger) Warn(args ...interface{})  { lg.logf(LevelWarn, "%s", fmt.Sprintln(args...)[:len(fmt.Sprintln(args...))-1]) }
+func (lg *Logger) Error(args ...interface{}) { lg.logf(LevelError, "%s", fmt.Sprintln(args...)[:len(fmt.Sprintln(args...))-1]) }
+
+// Fatalf는 Error 레벨로 기록한 뒤 로그 목적지를 정리하고 프로세스를 종료합니다.
+func (lg *Logger) Fatalf(format string, args ...interface{}) {
+	lg.logf(LevelError, format, args...)
+	lg.Close()
+	os.Exit(1)
+}
+
+// Close는 파일/syslog 목적지를 정리합니다. SIGINT/SIGTERM 처리기와 Fatalf에서 호출됩니다.
+func (lg *Logger) Close() error {
+	if lg == nil {
+		return nil
+	}
+	var firstErr error
+	if lg.rotator != nil {
+		if err := lg.rotator.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if lg.syslog != nil {
+		if err := lg.syslog.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// AppLog는 main()에서 Config.Log로 초기화되는 전역 로거입니다. 초기화 전에는 표준
+// 출력에만 쓰는 기본 로거를 가리켜, init() 단계의 로그 호출도 안전합니다.
+var AppLog = mustDefaultLogger()
+
+func mustDefaultLogger() *Logger {
+	lg, err := NewLogger(LogST{})
+	if err != nil {
+		// 표준 출력만 쓰는 기본 로거는 실패할 수 없습니다.
+		panic(err)
+	}
+	return lg
+}
+
+// InitLogger는 Config.Log 설정으로 AppLog를 다시 만듭니다. main()에서 플래그/설정
+// 로딩 직후, 다른 어떤 로그 호출보다도 먼저 호출되어야 합니다.
+func InitLogger(cfg LogST) error {
+	lg, err := NewLogger(cfg)
+	if err != nil {
+		return err
+	}
+	AppLog = lg
+	return nil
+}
+
+func Debugf(format string, args ...interface{}) { AppLog.Debugf(format, args...) }
+func Infof(format string, args ...interface{})  { AppLog.Infof(format, args...) }
+func Warnf(format string, args ...interface{})  { AppLog.Warnf(format, args...) }
+func Errorf(format string, args ...interface{}) { AppLog.Errorf(format, args...) }
+func Fatalf(format string, args ...interface{}) { AppLog.Fatalf(format, args...) }
+
+func Debug(args ...interface{}) { AppLog.Debug(args...) }
+func Info(args ...interface{})  { AppLog.Info(args...) }
+func Warn(args ...interface{})  { AppLog.Warn(args...) }
+func Error(args ...interface{}) { AppLog.Error(args...) }
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/deepch/vdk/av"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus 메트릭 정의. stream 관련 메트릭은 모두 uuid 레이블을 가집니다.
+var (
+	metricStreamUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stream_up",
+		Help: "Whether a stream is currently running (1) or not (0)",
+	}, []string{"uuid"})
+
+	metricStreamViewers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rtsp_stream_viewers",
+		Help: "Number of active viewers currently subscribed to a stream, by delivery protocol",
+	}, []string{"uuid", "protocol"})
+
+	metricStreamReconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rtsp_stream_reconnects_total",
+		Help: "Total number of reconnect attempts for a stream",
+	}, []string{"uuid"})
+
+	metricStreamPackets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "stream_packets_total",
+		Help: "Total number of packets fanned out for a stream by type",
+	}, []string{"uuid", "type"})
+
+	metricStreamBytesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rtsp_stream_bytes_received_total",
+		Help: "Total number of bytes received from a stream's source, by track",
+	}, []string{"uuid", "track"})
+
+	metricStreamBytesSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rtsp_stream_bytes_sent_total",
+		Help: "Total number of bytes sent to RTSP playback clients for a stream (UDP RTP and TCP interleaved)",
+	}, []string{"uuid"})
+
+	metricStreamRTCPJitter = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rtsp_stream_rtcp_jitter_seconds",
+		Help: "Most recent interarrival jitter reported in an RTCP receiver report, in seconds",
+	}, []string{"uuid"})
+
+	metricStreamRTPLost = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rtsp_stream_rtp_packets_lost_total",
+		Help: "Cumulative RTP packets lost, derived from RTCP receiver reports",
+	}, []string{"uuid"})
+
+	metricStreamsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rtsp_streams_total",
+		Help: "Number of configured streams by status",
+	}, []string{"status"})
+
+	metricStreamLastKeyframe = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stream_last_keyframe_timestamp_seconds",
+		Help: "Unix timestamp of the last keyframe seen for a stream",
+	}, []string{"uuid"})
+
+	metricStreamLastFrame = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stream_last_frame_seconds",
+		Help: "Unix timestamp of the last frame (video or audio) fanned out for a stream",
+	}, []string{"uuid"})
+
+	metricStreamViewerDrops = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stream_viewer_drops_total",
+		Help: "Cumulative number of viewers force-disconnected for falling behind the stream's ring buffer",
+	}, []string{"uuid"})
+
+	metricRTSPSessions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rtsp_sessions",
+		Help: "Number of active RTSPServer sessions",
+	})
+
+	metricRTSPBytesSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "rtsp_bytes_sent_total",
+		Help: "Total bytes written to RTSP clients (RTP payload + headers)",
+	})
+
+	metricServerLoadAvg1   = prometheus.NewGauge(prometheus.GaugeOpts{Name: "server_load_average_1m", Help: "1 minute load average"})
+	metricServerLoadAvg5   = prometheus.NewGauge(prometheus.GaugeOpts{Name: "server_load_average_5m", Help: "5 minute load average"})
+	metricServerLoadAvg15  = prometheus.NewGauge(prometheus.GaugeOpts{Name: "server_load_average_15m", Help: "15 minute load average"})
+	metricServerGoroutines = prometheus.NewGauge(prometheus.GaugeOpts{Name: "server_goroutines", Help: "Number of running goroutines"})
+	metricServerHeapAlloc  = prometheus.NewGauge(prometheus.GaugeOpts{Name: "server_heap_alloc_bytes", Help: "Go heap bytes allocated"})
+	metricServerUptime     = prometheus.NewGauge(prometheus.GaugeOpts{Name: "rtsp_server_uptime_seconds", Help: "Server uptime in seconds"})
+)
+
+// rtcpLostSeen은 스트림별 마지막으로 관측한 RTCP RR 누적 손실 패킷 수입니다. 카운터는
+// 증가만 해야 하므로, 새 RR이 올 때마다 이전 값과의 양수 델타만 더합니다.
+var (
+	rtcpLostMu   sync.Mutex
+	rtcpLostSeen = make(map[string]int32)
+)
+
+func init() {
+	prometheus.MustRegister(
+		metricStreamUp,
+		metricStreamViewers,
+		metricStreamReconnects,
+		metricStreamPackets,
+		metricStreamBytesReceived,
+		metricStreamBytesSent,
+		metricStreamRTCPJitter,
+		metricStreamRTPLost,
+		metricStreamsTotal,
+		metricStreamLastKeyframe,
+		metricStreamLastFrame,
+		metricStreamViewerDrops,
+		metricRTSPSessions,
+		metricRTSPBytesSent,
+		metricServerLoadAvg1,
+		metricServerLoadAvg5,
+		metricServerLoadAvg15,
+		metricServerGoroutines,
+		metricServerHeapAlloc,
+		metricServerUptime,
+	)
+}
+
+// recordPacketMetrics는 Config.cast를 통한 패킷 수신(ingest) 경로에서 호출되어
+// 타입별(video/audio/keyframe) 패킷 수, 수신 바이트 수, 마지막 키프레임 시각을 갱신합니다.
+// 이 경로는 RTSP 풀 소스, WHIP, RTSP PUBLISH 퍼블리셔 모두가 공유합니다.
+func recordPacketMetrics(uuid string, pck av.Packet, isVideo bool) {
+	packetType := "audio"
+	if isVideo {
+		packetType = "video"
+	}
+	if pck.IsKeyFrame {
+		metricStreamPackets.WithLabelValues(uuid, "keyframe").Inc()
+		metricStreamLastKeyframe.WithLabelValues(uuid).SetToCurrentTime()
+	}
+	metricStreamPackets.WithLabelValues(uuid, packetType).Inc()
+	metricStreamBytesReceived.WithLabelValues(uuid, packetType).Add(float64(len(pck.Data)))
+	metricStreamLastFrame.WithLabelValues(uuid).SetToCurrentTime()
+}
+
+// recordStreamBytesSent는 RTSPServer가 플레이어 세션으로 내보낸 바이트 수를
+// 스트림별로 누적합니다(UDP는 writeRTP, TCP interleaved는 streamToClient에서 호출).
+func recordStreamBytesSent(uuid string, n int) {
+	metricStreamBytesSent.WithLabelValues(uuid).Add(float64(n))
+}
+
+// recordRTCPReceiverReport는 readRTCPFeedback이 파싱한 RTCP RR의 jitter/누적
+// 손실값으로 메트릭을 갱신합니다. jitterTicks는 RTP 클럭(rtpClockRate) 단위입니다.
+func recordRTCPReceiverReport(uuid string, jitterTicks uint32, cumulativeLost int32) {
+	metricStreamRTCPJitter.WithLabelValues(uuid).Set(float64(jitterTicks) / float64(rtpClockRate))
+
+	rtcpLostMu.Lock()
+	prev, seen := rtcpLostSeen[uuid]
+	rtcpLostSeen[uuid] = cumulativeLost
+	rtcpLostMu.Unlock()
+	if seen && cumulativeLost > prev {
+		metricStreamRTPLost.WithLabelValues(uuid).Add(float64(cumulativeLost - prev))
+	}
+}
+
+// recordStreamState는 스트림 실행 상태를 갱신합니다. 시청자 수는 프로토콜별로
+// recordViewerConnect/recordViewerDisconnect에서 직접 갱신됩니다.
+func recordStreamState(uuid string, up bool) {
+	value := 0.0
+	if up {
+		value = 1
+	}
+	metricStreamUp.WithLabelValues(uuid).Set(value)
+}
+
+// recordReconnect는 RTSPWorkerLoop가 재연결을 시도할 때마다 호출됩니다.
+func recordReconnect(uuid string) {
+	metricStreamReconnects.WithLabelValues(uuid).Inc()
+}
+
+// recordViewerConnect/recordViewerDisconnect는 Config.clAd/clDe에서 프로토콜별
+// (rtsp/hls/ll-hls/webrtc/whep/relay) 시청자 수를 갱신하기 위해 호출됩니다.
+func recordViewerConnect(uuid, protocol string) {
+	metricStreamViewers.WithLabelValues(uuid, protocol).Inc()
+}
+
+func recordViewerDisconnect(uuid, protocol string) {
+	metricStreamViewers.WithLabelValues(uuid, protocol).Dec()
+}
+
+// recordRTSPBytesSent는 RTSP 세션으로 나간 바이트 수(RTP 헤더 포함)를 누적합니다.
+func recordRTSPBytesSent(n int) {
+	metricRTSPBytesSent.Add(float64(n))
+}
+
+// updateStreamRingMetrics는 /metrics 스크랩 시점에 각 스트림의 RingBuffer로부터
+// 시청자 드롭 누적치를 읽어 갱신합니다. Write/Read는 프로메테우스를 모르는
+// ringbuffer.go에서 원자적으로만 집계되므로, 이 값은 스크랩 시점에 동기화합니다.
+func updateStreamRingMetrics() {
+	Config.mutex.RLock()
+	defer Config.mutex.RUnlock()
+	for uuid, stream := range Config.Streams {
+		if stream.Ring == nil {
+			continue
+		}
+		metricStreamViewerDrops.WithLabelValues(uuid).Set(float64(stream.Ring.Drops()))
+	}
+}
+
+// updateStreamsTotalMetric은 /metrics 스크랩 시점에 Config.Streams를 status별
+// (active=Status true, idle=나머지)로 집계하고, 스트림별 stream_up도 현재 Status로
+// 맞춥니다.
+func updateStreamsTotalMetric() {
+	Config.mutex.RLock()
+	defer Config.mutex.RUnlock()
+	var active, idle float64
+	for uuid, stream := range Config.Streams {
+		recordStreamState(uuid, stream.Status)
+		if stream.Status {
+			active++
+		} else {
+			idle++
+		}
+	}
+	metricStreamsTotal.WithLabelValues("active").Set(active)
+	metricStreamsTotal.WithLabelValues("idle").Set(idle)
+}
+
+// updateServerMetrics는 HTTPAPIServerSystemStatus가 사용하는 동일한 gopsutil 수집기를
+// 재사용하여 서버 전역 메트릭을 갱신합니다.
+func updateServerMetrics() {
+	if l1, l5, l15, err := collectLoadAverage(); err == nil {
+		metricServerLoadAvg1.Set(l1)
+		metricServerLoadAvg5.Set(l5)
+		metricServerLoadAvg15.Set(l15)
+	}
+	metricServerGoroutines.Set(float64(runtime.NumGoroutine()))
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	metricServerHeapAlloc.Set(float64(memStats.HeapAlloc))
+	metricServerUptime.Set(time.Since(serverStartTime).Seconds())
+}
+
+// registerMetricsRoute는 /metrics 엔드포인트를 등록하고, 요청이 올 때마다
+// 서버 전역 메트릭을 최신 값으로 갱신합니다.
+func registerMetricsRoute(router *gin.Engine) {
+	handler := promhttp.Handler()
+	router.GET("/metrics", func(c *gin.Context) {
+		updateServerMetrics()
+		updateStreamRingMetrics()
+		updateRTSPSessionMetrics()
+		updateStreamsTotalMetric()
+		handler.ServeHTTP(c.Writer, c.Request)
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// runOnDemandProc는 StreamST.RunOnDemand로 띄운 외부 프로세스 하나의 생명주기를
+// 추적합니다. RTSPWorkerLoop로 직접 pull하는 스트림과 달리, URL이 "publisher"인
+// 경로는 이 프로세스가 실제 퍼블리셔(카메라를 깨우는 스크립트, ffmpeg push 등)를
+// 기동해야 비로소 트랙이 생깁니다.
+type runOnDemandProc struct {
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	lastRead time.Time
+}
+
+var (
+	runOnDemandProcs   = make(map[string]*runOnDemandProc)
+	runOnDemandProcsMu sync.Mutex
+)
+
+const (
+	runOnDemandDefaultStartTimeout = 10 * time.Second
+	runOnDemandDefaultCloseAfter   = 10 * time.Second
+)
+
+// EnsureSourceRunning은 클라이언트가 한 경로를 구독하기 직전(Config.clAd)에 호출됩니다.
+// RunOnDemand가 설정되어 있지 않으면 아무 일도 하지 않습니다. 아직 떠 있지 않으면 셸
+// 명령으로 기동하고, 트랙이 나타날 때까지(RunOnDemandStartTimeout) 대기한 뒤 돌아옵니다.
+// 이미 떠 있으면 유휴 타이머만 갱신하고 즉시 반환합니다.
+func (element *ConfigST) EnsureSourceRunning(uuid string) {
+	element.mutex.RLock()
+	stream, ok := element.Streams[uuid]
+	element.mutex.RUnlock()
+	if !ok || stream.RunOnDemand == "" {
+		return
+	}
+
+	runOnDemandProcsMu.Lock()
+	proc, exists := runOnDemandProcs[uuid]
+	if !exists {
+		proc = &runOnDemandProc{}
+		runOnDemandProcs[uuid] = proc
+	}
+	runOnDemandProcsMu.Unlock()
+
+	proc.mu.Lock()
+	proc.lastRead = time.Now()
+	justStarted := proc.cmd == nil
+	if justStarted {
+		cmd := exec.Command("sh", "-c", stream.RunOnDemand)
+		cmd.Env = append(cmd.Env, "RTSP_PATH="+uuid)
+		if err := cmd.Start(); err != nil {
+			Errorf("RunOnDemand 실행 실패 %s: %v", uuid, err)
+			justStarted = false
+		} else {
+			proc.cmd = cmd
+			go proc.watchIdle(uuid, runOnDemandCloseAfter(stream.RunOnDemandCloseAfter))
+		}
+	}
+	proc.mu.Unlock()
+
+	if justStarted {
+		waitForCodecs(uuid, runOnDemandStartTimeout(stream.RunOnDemandStartTimeout))
+	}
+}
+
+func runOnDemandStartTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return runOnDemandDefaultStartTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func runOnDemandCloseAfter(seconds int) time.Duration {
+	if seconds <= 0 {
+		return runOnDemandDefaultCloseAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// waitForCodecs는 RunOnDemand로 기동한 퍼블리셔가 ANNOUNCE/WHIP으로 트랙을 등록할
+// 때까지 기다립니다. Config.coGe 자체도 최대 5초 재시도하므로, timeout이 그보다 길면
+// 여러 차례 다시 불러 전체 대기시간을 채웁니다.
+func waitForCodecs(uuid string, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for {
+		if Config.coGe(uuid) != nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			return
+		}
+	}
+}
+
+// watchIdle은 마지막 구독(EnsureSourceRunning 호출) 이후 closeAfter가 지나면
+// RunOnDemand 프로세스를 종료합니다.
+func (proc *runOnDemandProc) watchIdle(uuid string, closeAfter time.Duration) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		proc.mu.Lock()
+		idle := time.Since(proc.lastRead) > closeAfter
+		cmd := proc.cmd
+		if idle && cmd != nil {
+			proc.cmd = nil
+		}
+		proc.mu.Unlock()
+
+		if idle && cmd != nil {
+			if cmd.Process != nil {
+				Infof("RunOnDemand 프로세스 종료 (유휴 %s 초과): %s", closeAfter, uuid)
+				cmd.Process.Kill()
+			}
+			return
+		}
+	}
+}
+
+// RunOnInitIfConfigured는 스트림 등록 시 한 번 RunOnInit 명령을 실행합니다. 트랙이
+// 준비되길 기다리지 않고 그냥 기동만 합니다(카메라 전원을 올리는 스크립트 등).
+func RunOnInitIfConfigured(uuid string, stream StreamST) {
+	if stream.RunOnInit == "" {
+		return
+	}
+	cmd := exec.Command("sh", "-c", stream.RunOnInit)
+	cmd.Env = append(cmd.Env, "RTSP_PATH="+uuid)
+	if err := cmd.Start(); err != nil {
+		Errorf("RunOnInit 실행 실패 %s: %v", uuid, err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/deepch/vdk/av"
+)
+
+// streamRingBufferSize는 스트림당 RingBuffer 슬롯 수입니다. reader가 이 슬롯 수보다
+// 뒤처지면 느린 소비자로 간주되어 강제로 끊깁니다.
+const streamRingBufferSize = 1024
+
+// RingBuffer는 단일 writer(RTSP 소스)·다중 reader(RTSP/WebRTC/HLS/WHIP/브로드캐스트
+// 소비자) 구조의 스트림 패킷 버퍼입니다. 예전 `cast`는 매 패킷마다 전역 뮤텍스를 쥐고
+// 각 viewer 채널에 동기적으로 기록하며 채널이 가득 차면 패킷을 버렸는데, 그 방식은
+// 느린 뷰어 하나가 다른 모든 시청자를 지연시킬 수 있었습니다. RingBuffer의 writer는
+// 원자적 인덱스 연산만으로 슬롯에 기록하고(절대 블록하지 않음), reader는 각자의 읽기
+// 커서를 들고 다니며 쓰기가 있을 때마다 갈아끼워지는 notify 채널로 깨어납니다.
+type RingBuffer struct {
+	buf      []av.Packet
+	size     uint64
+	writeIdx uint64 // atomic, 다음에 기록할 절대 시퀀스 번호
+
+	mu     sync.Mutex
+	notify chan struct{}
+
+	readers int32 // atomic, 현재 연결된 reader 수
+
+	packets     uint64 // atomic, Write로 기록된 누적 패킷 수
+	bytes       uint64 // atomic, Write로 기록된 누적 payload 바이트 수
+	lastFrameNs int64  // atomic, 마지막 Write 시각(UnixNano)
+	drops       uint64 // atomic, 뒤처져 강제로 끊긴 reader 수 (시청자 드롭)
+}
+
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = streamRingBufferSize
+	}
+	return &RingBuffer{
+		buf:    make([]av.Packet, size),
+		size:   uint64(size),
+		notify: make(chan struct{}),
+	}
+}
+
+// Write는 다음 슬롯에 패킷을 기록하고 대기 중인 reader들을 깨웁니다. 느린 reader를
+// 기다리지 않으므로 RTSP 소스 루프를 절대 막지 않습니다. writer는 하나뿐이므로 인덱스
+// 예약에 원자적 CAS가 필요하지는 않지만, 슬롯에 다 쓴 *뒤에* writeIdx를 퍼블리시해야
+// 합니다 — 먼저 인덱스를 올리면 reader가 아직 쓰는 중인 슬롯을 읽어 torn read가 됩니다.
+func (rb *RingBuffer) Write(pkt av.Packet) {
+	idx := atomic.LoadUint64(&rb.writeIdx)
+	rb.buf[idx%rb.size] = pkt
+	atomic.StoreUint64(&rb.writeIdx, idx+1)
+
+	atomic.AddUint64(&rb.packets, 1)
+	atomic.AddUint64(&rb.bytes, uint64(len(pkt.Data)))
+	atomic.StoreInt64(&rb.lastFrameNs, time.Now().UnixNano())
+
+	rb.mu.Lock()
+	old := rb.notify
+	rb.notify = make(chan struct{})
+	rb.mu.Unlock()
+	close(old)
+}
+
+// ReaderCount는 현재 연결된 reader 수를 반환합니다 (시청자 수 집계에 사용).
+func (rb *RingBuffer) ReaderCount() int {
+	return int(atomic.LoadInt32(&rb.readers))
+}
+
+// PacketCount는 Write로 기록된 누적 패킷 수를 반환합니다.
+func (rb *RingBuffer) PacketCount() uint64 {
+	return atomic.LoadUint64(&rb.packets)
+}
+
+// ByteCount는 Write로 기록된 누적 payload 바이트 수를 반환합니다.
+func (rb *RingBuffer) ByteCount() uint64 {
+	return atomic.LoadUint64(&rb.bytes)
+}
+
+// LastFrameTime은 마지막으로 Write가 호출된 시각을 반환합니다. 아직 한 번도
+// 기록된 적이 없으면 제로 값을 반환합니다.
+func (rb *RingBuffer) LastFrameTime() time.Time {
+	ns := atomic.LoadInt64(&rb.lastFrameNs)
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// Drops는 뒤처져 강제로 끊긴 reader(시청자)의 누적 수를 반환합니다.
+func (rb *RingBuffer) Drops() uint64 {
+	return atomic.LoadUint64(&rb.drops)
+}
+
+// RingReader는 RingBuffer 하나에 대한 독립적인 읽기 커서입니다.
+type RingReader struct {
+	rb      *RingBuffer
+	readIdx uint64
+	stopCh  chan struct{}
+	stopped int32
+}
+
+// NewReader는 과거 패킷은 건너뛰고 현재 쓰기 위치부터 읽기 시작하는 reader를 만듭니다.
+func (rb *RingBuffer) NewReader() *RingReader {
+	atomic.AddInt32(&rb.readers, 1)
+	return &RingReader{
+		rb:      rb,
+		readIdx: atomic.LoadUint64(&rb.writeIdx),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// Read는 다음 패킷을 기다립니다. timeout 동안 새 패킷이 없으면 (false)를 반환하고,
+// 느린 소비로 인해 링 크기를 넘겨 뒤처지면 reader를 강제로 끊고 (false)를 반환합니다.
+func (r *RingReader) Read(timeout time.Duration) (av.Packet, bool) {
+	rb := r.rb
+	for {
+		if atomic.LoadInt32(&r.stopped) == 1 {
+			return av.Packet{}, false
+		}
+		if atomic.LoadUint64(&rb.writeIdx) > r.readIdx {
+			break
+		}
+		rb.mu.Lock()
+		ch := rb.notify
+		rb.mu.Unlock()
+		select {
+		case <-ch:
+		case <-time.After(timeout):
+			return av.Packet{}, false
+		case <-r.stopCh:
+			return av.Packet{}, false
+		}
+	}
+
+	if atomic.LoadUint64(&rb.writeIdx)-r.readIdx > rb.size {
+		// 링 버퍼를 한 바퀴 넘게 따라잡지 못해 이미 덮어써진 슬롯을 읽게 될 상황.
+		// writer를 막는 대신 이 reader를 강제로 끊습니다.
+		atomic.AddUint64(&rb.drops, 1)
+		r.Close()
+		return av.Packet{}, false
+	}
+
+	pkt := rb.buf[r.readIdx%rb.size]
+	r.readIdx++
+	return pkt, true
+}
+
+// Close는 reader를 종료하고 RingBuffer의 reader 카운트를 줄입니다. 여러 번 호출해도
+// 안전합니다.
+func (r *RingReader) Close() {
+	if atomic.CompareAndSwapInt32(&r.stopped, 0, 1) {
+		atomic.AddInt32(&r.rb.readers, -1)
+		close(r.stopCh)
+	}
+}
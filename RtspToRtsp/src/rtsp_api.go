@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 )
@@ -14,6 +13,8 @@ type RTSPStreamInfo struct {
 	Status  bool   `json:"status"`
 }
 
+// GetRTSPURLForAPI는 스트림 존재 여부만 확인합니다. 사용자별 ACL/IP 허용 목록 검사는
+// 호출자인 HandleRTSPStreamInfo에서 authenticateRTSPRequest/streamAllowed로 수행합니다.
 func GetRTSPURLForAPI(uuid string, hostname string) (string, error) {
 	if idx := strings.Index(hostname, ":"); idx > 0 {
 		hostname = hostname[:idx]
@@ -46,6 +47,19 @@ func GetRTSPURLForAPI(uuid string, hostname string) (string, error) {
 }
 
 func HandleRTSPStreamInfo(w http.ResponseWriter, r *http.Request, uuid string) {
+	user, authOK := authenticateRTSPRequest(r.Header.Get("Authorization"), "GET", r.URL.Path, r.RemoteAddr, uuid, false)
+	if !authOK {
+		w.Header().Set("WWW-Authenticate", rtspWWWAuthenticateHeader())
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	// user가 nil이면 Config.Auth.Users가 비어 있어 인증을 요구하지 않는 배포입니다.
+	if user != nil && !streamAllowed(user, uuid) {
+		Warnf("RTSP stream info access denied: user=%s stream=%s from=%s", user.Username, uuid, r.RemoteAddr)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	Config.mutex.RLock()
 	defer Config.mutex.RUnlock()
 
@@ -70,7 +84,7 @@ func HandleRTSPStreamInfo(w http.ResponseWriter, r *http.Request, uuid string) {
 	if stream.OnDemand {
 		go func() {
 			if err := RunIFNotRun(streamUUID); err != nil {
-				log.Printf("온디맨드 스트림 시작 실패 %s: %v", streamUUID, err)
+				Errorf("온디맨드 스트림 시작 실패 %s: %v", streamUUID, err)
 			}
 		}()
 	}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nonce 저장소. DIGEST 챌린지에 사용한 nonce를 짧은 TTL 동안 기억해 재생 공격을 막습니다.
+var (
+	rtspNonces   = make(map[string]time.Time)
+	rtspNoncesMu sync.Mutex
+)
+
+const rtspNonceTTL = 5 * time.Minute
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRTSPNonce는 새 nonce를 만들어 저장소에 등록합니다.
+func generateRTSPNonce() string {
+	nonce := pseudoUUID()
+	rtspNoncesMu.Lock()
+	rtspNonces[nonce] = time.Now().Add(rtspNonceTTL)
+	rtspNoncesMu.Unlock()
+	return nonce
+}
+
+// validRTSPNonce는 nonce가 아직 만료되지 않았는지 확인합니다.
+func validRTSPNonce(nonce string) bool {
+	rtspNoncesMu.Lock()
+	defer rtspNoncesMu.Unlock()
+	expiry, ok := rtspNonces[nonce]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiry) {
+		delete(rtspNonces, nonce)
+		return false
+	}
+	return true
+}
+
+// parseAuthHeaderParams는 `Digest key="value", key2=value2` 형식의 값을 map으로 분해합니다.
+func parseAuthHeaderParams(value string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(kv[0])
+		val := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		params[key] = val
+	}
+	return params
+}
+
+// findAuthUser는 먼저 uuid 경로에 설정된 자격 증명(StreamST.PublishUser/ReadUser)을
+// 확인하고, 없으면 전역 Config.Auth.Users에서 찾습니다. uuid가 비어 있으면(스트림
+// 컨텍스트가 없는 요청) 경로별 자격 증명은 건너뜁니다. forPublish는 PublishUser/
+// PublishPass와 ReadUser/ReadPass 중 어느 쪽을 확인할지를 결정합니다.
+func findAuthUser(username, uuid string, forPublish bool) (*AuthUserST, bool) {
+	if uuid != "" {
+		if user, ok := pathAuthUser(username, uuid, forPublish); ok {
+			return user, true
+		}
+	}
+	for i := range Config.Auth.Users {
+		if Config.Auth.Users[i].Username == username {
+			return &Config.Auth.Users[i], true
+		}
+	}
+	return nil, false
+}
+
+// pathAuthUser는 StreamST에 설정된 경로별 자격 증명을 일회용 AuthUserST로 감싸
+// 돌려줍니다. Streams는 항상 해당 uuid 하나로 좁혀지므로 다른 경로에는 쓸 수 없습니다.
+func pathAuthUser(username, uuid string, forPublish bool) (*AuthUserST, bool) {
+	Config.mutex.RLock()
+	stream, ok := Config.Streams[uuid]
+	Config.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	if forPublish {
+		if stream.PublishUser == "" || stream.PublishUser != username {
+			return nil, false
+		}
+		return &AuthUserST{Username: stream.PublishUser, Password: stream.PublishPass, Streams: []string{uuid}}, true
+	}
+
+	if stream.ReadUser == "" || stream.ReadUser != username {
+		return nil, false
+	}
+	return &AuthUserST{
+		Username:   stream.ReadUser,
+		Password:   stream.ReadPass,
+		Streams:    []string{uuid},
+		ReadOnly:   true,
+		AllowedIPs: stream.ReadIPs,
+	}, true
+}
+
+// pathRequiresAuth는 uuid 경로에 경로별 자격 증명이 설정되어 있는지 확인합니다.
+// 전역 Config.Auth.Users가 비어 있어도 경로별 자격 증명이 있으면 인증을 강제해야
+// 하기 때문에 authenticateRTSPRequest의 "인증 비활성화" 단축 경로에서 씁니다.
+func pathRequiresAuth(uuid string, forPublish bool) bool {
+	if uuid == "" {
+		return false
+	}
+	Config.mutex.RLock()
+	stream, ok := Config.Streams[uuid]
+	Config.mutex.RUnlock()
+	if !ok {
+		return false
+	}
+	if forPublish {
+		return stream.PublishUser != ""
+	}
+	return stream.ReadUser != ""
+}
+
+// ipAllowed는 user.AllowedIPs가 비어 있으면 항상 허용하고, 아니면 CIDR 목록과 대조합니다.
+func ipAllowed(user *AuthUserST, remoteAddr string) bool {
+	if len(user.AllowedIPs) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range user.AllowedIPs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			if cidr == host {
+				return true
+			}
+			continue
+		}
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// streamAllowed는 user.Streams가 비어 있으면 모든 스트림을 허용하고, 아니면 uuid가
+// 목록에 있는지 확인합니다.
+func streamAllowed(user *AuthUserST, uuid string) bool {
+	if len(user.Streams) == 0 {
+		return true
+	}
+	for _, s := range user.Streams {
+		if strings.EqualFold(s, uuid) {
+			return true
+		}
+	}
+	return false
+}
+
+// rtspAuthRealm은 Digest 챌린지와 HA1 계산이 공유하는 realm을 반환합니다.
+// Config.Auth.Realm이 비어 있으면 기본값으로 대체하며, 챌린지에 실제로 보낸 realm과
+// HA1이 다르면 클라이언트가 올바른 비밀번호를 보내도 다이제스트가 절대 일치하지 않습니다.
+func rtspAuthRealm() string {
+	if Config.Auth.Realm == "" {
+		return "RtspToRtsp"
+	}
+	return Config.Auth.Realm
+}
+
+// rtspWWWAuthenticateHeader는 새 nonce를 발급하여 Digest 챌린지 헤더 값을 만듭니다.
+func rtspWWWAuthenticateHeader() string {
+	nonce := generateRTSPNonce()
+	return fmt.Sprintf(`Digest realm="%s", nonce="%s", algorithm=MD5`, rtspAuthRealm(), nonce)
+}
+
+// authenticateRTSPRequest는 Authorization 헤더(Digest 우선, Basic 폴백)를 검증하고,
+// 성공 시 인증된 사용자를 반환합니다. uuid/forPublish는 StreamST.PublishUser나
+// ReadUser가 설정된 경로별 자격 증명을 찾는 데 쓰입니다. Config.Auth.Users가 비어
+// 있고 해당 경로에도 자격 증명이 설정되어 있지 않으면 인증을 요구하지 않아 기존
+// 배포와 호환됩니다.
+func authenticateRTSPRequest(authorization, method, uri, remoteAddr, uuid string, forPublish bool) (*AuthUserST, bool) {
+	if len(Config.Auth.Users) == 0 && !pathRequiresAuth(uuid, forPublish) {
+		return nil, true
+	}
+	if authorization == "" {
+		return nil, false
+	}
+
+	if strings.HasPrefix(authorization, "Digest ") {
+		params := parseAuthHeaderParams(strings.TrimPrefix(authorization, "Digest "))
+		username := params["username"]
+		nonce := params["nonce"]
+		response := params["response"]
+		if username == "" || nonce == "" || response == "" {
+			return nil, false
+		}
+		if !validRTSPNonce(nonce) {
+			return nil, false
+		}
+		user, ok := findAuthUser(username, uuid, forPublish)
+		if !ok {
+			return nil, false
+		}
+		ha1 := md5Hex(fmt.Sprintf("%s:%s:%s", username, rtspAuthRealm(), user.Password))
+		ha2 := md5Hex(fmt.Sprintf("%s:%s", method, uri))
+		expected := md5Hex(fmt.Sprintf("%s:%s:%s", ha1, nonce, ha2))
+		if expected != response {
+			return nil, false
+		}
+		if !ipAllowed(user, remoteAddr) {
+			return nil, false
+		}
+		return user, true
+	}
+
+	if strings.HasPrefix(authorization, "Basic ") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(authorization, "Basic "))
+		if err != nil {
+			return nil, false
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return nil, false
+		}
+		user, ok := findAuthUser(parts[0], uuid, forPublish)
+		if !ok || user.Password != parts[1] {
+			return nil, false
+		}
+		if !ipAllowed(user, remoteAddr) {
+			return nil, false
+		}
+		return user, true
+	}
+
+	return nil, false
+}
+
+// checkAuth는 DESCRIBE/SETUP/PLAY 요청 처리 전에 호출되어 인증 및 스트림 ACL을 강제합니다.
+// 인증에 실패하면 401과 WWW-Authenticate를, 스트림 접근이 거부되면 403을 보내고 false를
+// 반환합니다 (호출자는 이후 처리를 중단해야 합니다).
+func (s *RTSPServer) checkAuth(conn net.Conn, headers map[string]string, method, uri, streamUUID, cseq string) bool {
+	remoteAddr := conn.RemoteAddr().String()
+
+	user, ok := authenticateRTSPRequest(headers["Authorization"], method, uri, remoteAddr, streamUUID, false)
+	if !ok {
+		Warnf("RTSP auth failed: method=%s stream=%s from=%s", method, streamUUID, remoteAddr)
+		response := "RTSP/1.0 401 Unauthorized\r\n" +
+			"CSeq: " + cseq + "\r\n" +
+			"WWW-Authenticate: " + rtspWWWAuthenticateHeader() + "\r\n" +
+			"\r\n"
+		conn.Write([]byte(response))
+		return false
+	}
+
+	if user != nil && streamUUID != "" && !streamAllowed(user, streamUUID) {
+		Warnf("RTSP access denied: user=%s stream=%s from=%s", user.Username, streamUUID, remoteAddr)
+		response := "RTSP/1.0 403 Forbidden\r\n" +
+			"CSeq: " + cseq + "\r\n" +
+			"\r\n"
+		conn.Write([]byte(response))
+		return false
+	}
+
+	return true
+}
+
+// checkAnnounceAuth는 ANNOUNCE(퍼블리시) 요청에 대해 인증/ACL을 확인합니다. checkAuth와
+// 동일한 401/403 규약을 따르되, ReadOnly로 표시된 사용자의 퍼블리시는 추가로 거부합니다.
+func (s *RTSPServer) checkAnnounceAuth(conn net.Conn, headers map[string]string, uri, streamUUID, cseq string) bool {
+	remoteAddr := conn.RemoteAddr().String()
+
+	user, ok := authenticateRTSPRequest(headers["Authorization"], "ANNOUNCE", uri, remoteAddr, streamUUID, true)
+	if !ok {
+		Warnf("RTSP publish auth failed: stream=%s from=%s", streamUUID, remoteAddr)
+		response := "RTSP/1.0 401 Unauthorized\r\n" +
+			"CSeq: " + cseq + "\r\n" +
+			"WWW-Authenticate: " + rtspWWWAuthenticateHeader() + "\r\n" +
+			"\r\n"
+		conn.Write([]byte(response))
+		return false
+	}
+
+	if user == nil {
+		// Config.Auth.Users가 비어 있어 인증이 비활성화된 경우 기존 PLAY/DESCRIBE와
+		// 동일하게 허용합니다.
+		return true
+	}
+
+	if user.ReadOnly {
+		Warnf("RTSP publish denied (read-only user): user=%s stream=%s from=%s", user.Username, streamUUID, remoteAddr)
+		response := "RTSP/1.0 403 Forbidden\r\n" +
+			"CSeq: " + cseq + "\r\n" +
+			"\r\n"
+		conn.Write([]byte(response))
+		return false
+	}
+
+	if !streamAllowed(user, streamUUID) {
+		Warnf("RTSP publish denied: user=%s stream=%s from=%s", user.Username, streamUUID, remoteAddr)
+		response := "RTSP/1.0 403 Forbidden\r\n" +
+			"CSeq: " + cseq + "\r\n" +
+			"\r\n"
+		conn.Write([]byte(response))
+		return false
+	}
+
+	return true
+}
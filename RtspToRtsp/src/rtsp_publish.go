@@ -0,0 +1,437 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/deepch/vdk/av"
+	"github.com/deepch/vdk/codec/aacparser"
+	"github.com/deepch/vdk/codec/h264parser"
+)
+
+// publishSDP는 ANNOUNCE 본문(SDP)에서 추출한, codec data를 만드는 데 필요한 최소한의
+// 트랙 정보를 담습니다.
+type publishSDP struct {
+	videoSPS, videoPPS []byte
+	hasAudio           bool
+	audioConfig        []byte
+}
+
+// parsePublishSDP는 ANNOUNCE 요청 본문에서 H264용 sprop-parameter-sets와
+// (있다면) mpeg4-generic AAC용 config를 파싱합니다. 두 값 모두 a=fmtp 라인에
+// 실려 오는 것을 전제로 합니다.
+func parsePublishSDP(body string) (*publishSDP, error) {
+	sdp := &publishSDP{}
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "m=audio") {
+			sdp.hasAudio = true
+			continue
+		}
+		if !strings.HasPrefix(line, "a=fmtp:") {
+			continue
+		}
+
+		if idx := strings.Index(line, "sprop-parameter-sets="); idx >= 0 {
+			value := line[idx+len("sprop-parameter-sets="):]
+			if end := strings.IndexByte(value, ';'); end >= 0 {
+				value = value[:end]
+			}
+			parts := strings.SplitN(value, ",", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("sprop-parameter-sets must contain SPS and PPS")
+			}
+			sps, err := base64.StdEncoding.DecodeString(parts[0])
+			if err != nil {
+				return nil, fmt.Errorf("decode SPS: %v", err)
+			}
+			pps, err := base64.StdEncoding.DecodeString(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("decode PPS: %v", err)
+			}
+			sdp.videoSPS = sps
+			sdp.videoPPS = pps
+		}
+
+		if idx := strings.Index(line, "config="); idx >= 0 {
+			value := line[idx+len("config="):]
+			if end := strings.IndexByte(value, ';'); end >= 0 {
+				value = value[:end]
+			}
+			if config, err := hex.DecodeString(strings.TrimSpace(value)); err == nil {
+				sdp.audioConfig = config
+			}
+		}
+	}
+
+	if sdp.videoSPS == nil || sdp.videoPPS == nil {
+		return nil, fmt.Errorf("no H264 sprop-parameter-sets found in SDP")
+	}
+	return sdp, nil
+}
+
+// buildCodecData는 파싱된 SDP 트랙 정보로부터 Config.coAd에 넘길 codec data를 만듭니다.
+// 오디오 codec 파싱에 실패해도 비디오만으로 계속 진행합니다.
+func (sdp *publishSDP) buildCodecData() ([]av.CodecData, error) {
+	videoCodec, err := h264parser.NewCodecDataFromSPSAndPPS(sdp.videoSPS, sdp.videoPPS)
+	if err != nil {
+		return nil, fmt.Errorf("h264 codec data: %v", err)
+	}
+	codecs := []av.CodecData{videoCodec}
+
+	if sdp.hasAudio && len(sdp.audioConfig) > 0 {
+		audioCodec, err := aacparser.NewCodecDataFromMPEG4AudioConfigBytes(sdp.audioConfig)
+		if err != nil {
+			Warnf("ANNOUNCE: failed to parse AAC config, continuing video-only: %v", err)
+		} else {
+			codecs = append(codecs, audioCodec)
+		}
+	}
+	return codecs, nil
+}
+
+// anyAudioCodec은 codecs에서 첫 오디오 트랙을 찾습니다. emitAACAU가 RTP 타임스탬프를
+// 초 단위 PTS로 환산할 때 필요한 샘플레이트(클록 레이트)를 읽는 데 씁니다.
+func anyAudioCodec(codecs []av.CodecData) (av.AudioCodecData, bool) {
+	for _, codec := range codecs {
+		if audio, ok := codec.(av.AudioCodecData); ok {
+			return audio, true
+		}
+	}
+	return nil, false
+}
+
+// handleAnnounce는 외부 인코더(OBS/ffmpeg 등)가 보낸 ANNOUNCE 요청을 처리합니다.
+// SDP 본문을 파싱해 codec data를 만들고, Config.Streams에 퍼블리셔용 스트림 항목을
+// 등록한 뒤 이어지는 SETUP/RECORD가 이 스트림을 찾을 수 있도록 s.streams에도 등록합니다.
+func (s *RTSPServer) handleAnnounce(conn net.Conn, streamUUID string, urlPath string, cseq string, headers map[string]string, rawRequest string, sessionID string) {
+	if !s.checkAnnounceAuth(conn, headers, urlPath, streamUUID, cseq) {
+		return
+	}
+
+	body := rawRequest
+	if idx := strings.Index(rawRequest, "\r\n\r\n"); idx >= 0 {
+		body = rawRequest[idx+4:]
+	}
+
+	sdp, err := parsePublishSDP(body)
+	if err != nil {
+		Errorf("ANNOUNCE: failed to parse SDP for %s: %v", streamUUID, err)
+		conn.Write([]byte("RTSP/1.0 400 Bad Request\r\nCSeq: " + cseq + "\r\n\r\n"))
+		return
+	}
+
+	codecs, err := sdp.buildCodecData()
+	if err != nil {
+		Errorf("ANNOUNCE: failed to build codec data for %s: %v", streamUUID, err)
+		conn.Write([]byte("RTSP/1.0 400 Bad Request\r\nCSeq: " + cseq + "\r\n\r\n"))
+		return
+	}
+
+	Config.mutex.Lock()
+	if _, ok := Config.Streams[streamUUID]; !ok {
+		Config.Streams[streamUUID] = StreamST{
+			URL:      "publisher",
+			OnDemand: false,
+			Ring:     NewRingBuffer(streamRingBufferSize),
+		}
+	}
+	Config.mutex.Unlock()
+	Config.coAd(streamUUID, codecs)
+
+	s.RegisterStream(streamUUID, "publisher")
+
+	audioClockRate := 0
+	if sdp.hasAudio {
+		if audioCodec, ok := anyAudioCodec(codecs); ok {
+			audioClockRate = audioCodec.SampleRate()
+		}
+	}
+
+	s.sessionsMtx.Lock()
+	session, exists := s.sessions[sessionID]
+	if !exists {
+		session = &RTSPSession{ID: sessionID, Conn: conn, audioChannel: -1}
+		s.sessions[sessionID] = session
+	}
+	session.StreamUUID = streamUUID
+	session.CSeq = cseq
+	session.Publishing = true
+	session.LastActive = time.Now()
+	session.audioClockRate = audioClockRate
+	s.sessionsMtx.Unlock()
+
+	response := "RTSP/1.0 200 OK\r\n" +
+		"CSeq: " + cseq + "\r\n" +
+		"\r\n"
+	Debugf("Sending ANNOUNCE response: %s", response)
+	conn.Write([]byte(response))
+	Info("RTSP publisher announced stream", streamUUID)
+}
+
+// handleRecord는 RECORD 요청에 응답하고, 세션의 전송 방식에 맞춰 퍼블리셔로부터
+// 들어오는 RTP를 받기 시작합니다. TCP interleaved 전송은 handleConnection의 읽기
+// 루프에서 handleInterleavedFrame으로 바로 전달되므로 여기서는 UDP 전송만 별도
+// 고루틴을 띄웁니다.
+func (s *RTSPServer) handleRecord(conn net.Conn, streamUUID string, cseq string, sessionID string) {
+	s.sessionsMtx.Lock()
+	session := s.sessions[sessionID]
+	if session != nil {
+		session.publishStart = time.Now()
+	}
+	s.sessionsMtx.Unlock()
+
+	response := "RTSP/1.0 200 OK\r\n" +
+		"CSeq: " + cseq + "\r\n" +
+		"Session: " + sessionID + "\r\n" +
+		"Range: npt=0.000-\r\n" +
+		"\r\n"
+	Debugf("Sending RECORD response: %s", response)
+	conn.Write([]byte(response))
+
+	if session != nil && session.Transport == "udp" {
+		go s.receivePublishedUDP(session)
+	}
+	Info("RTSP publisher started recording stream", streamUUID)
+}
+
+// rtpHeaderInfo는 depacketizer가 PTS 계산에 필요한 RTP 헤더 필드입니다.
+type rtpHeaderInfo struct {
+	Timestamp uint32
+	Marker    bool
+}
+
+// parseRTPPacket은 RTP 헤더(+ 필요시 CSRC/확장 헤더)를 건너뛰어 헤더 정보와 페이로드를
+// 돌려줍니다.
+func parseRTPPacket(data []byte) (hdr rtpHeaderInfo, payload []byte, ok bool) {
+	if len(data) < 12 || data[0]>>6 != 2 {
+		return rtpHeaderInfo{}, nil, false
+	}
+	csrcCount := int(data[0] & 0x0F)
+	hasExtension := data[0]&0x10 != 0
+	hdr.Marker = data[1]&0x80 != 0
+	hdr.Timestamp = binary.BigEndian.Uint32(data[4:8])
+	headerLen := 12 + csrcCount*4
+	if len(data) < headerLen {
+		return rtpHeaderInfo{}, nil, false
+	}
+	if hasExtension {
+		if len(data) < headerLen+4 {
+			return rtpHeaderInfo{}, nil, false
+		}
+		extWords := int(binary.BigEndian.Uint16(data[headerLen+2 : headerLen+4]))
+		headerLen += 4 + extWords*4
+		if len(data) < headerLen {
+			return rtpHeaderInfo{}, nil, false
+		}
+	}
+	return hdr, data[headerLen:], true
+}
+
+// handleInterleavedFrame은 TCP interleaved 전송($ 프레임)으로 들어온 데이터를 퍼블리시
+// 중인 세션의 RTP depacketizer로 전달합니다. 채널 0은 비디오, session.audioChannel로
+// SETUP에서 배정한 채널(보통 2)은 오디오로 라우팅합니다. 하나의 conn.Read 결과에 여러
+// 프레임이 이어 붙어 올 수 있어 순회하며 처리하지만, 기존 RTSP 요청 파싱과 마찬가지로
+// 프레임이 읽기 경계에서 잘리는 경우는 지원하지 않습니다.
+func (s *RTSPServer) handleInterleavedFrame(sessionID string, data []byte) {
+	s.sessionsMtx.RLock()
+	session := s.sessions[sessionID]
+	s.sessionsMtx.RUnlock()
+	if session == nil || !session.Publishing {
+		return
+	}
+
+	for len(data) >= 4 && data[0] == 0x24 {
+		channel := data[1]
+		length := int(binary.BigEndian.Uint16(data[2:4]))
+		if len(data) < 4+length {
+			return
+		}
+		payload := data[4 : 4+length]
+		switch {
+		case channel == 0:
+			s.ingestRTPPacket(session, payload)
+		case session.audioChannel >= 0 && int(channel) == session.audioChannel:
+			s.ingestAACRTPPacket(session, payload)
+		}
+		data = data[4+length:]
+	}
+}
+
+// rtpPTS는 RTP 타임스탬프를 세션의 해당 트랙 기준 타임스탬프와 clockRate로부터
+// 실제 미디어 타임라인상의 PTS로 환산합니다. 최초 패킷의 타임스탬프를 0으로 잡고,
+// 이후 패킷은 32비트 랩어라운드를 signed delta로 처리해 상대 시간을 구합니다.
+func rtpPTS(baseTS uint32, ts uint32, clockRate int) time.Duration {
+	delta := int32(ts - baseTS)
+	return time.Duration(delta) * time.Second / time.Duration(clockRate)
+}
+
+// receivePublishedUDP는 SETUP에서 할당한 세션의 RTP 소켓으로 들어오는 UDP 패킷을
+// 읽어 depacketizer로 전달합니다. 세션이 정리되거나 소켓이 닫히면 종료합니다.
+func (s *RTSPServer) receivePublishedUDP(session *RTSPSession) {
+	if session.RTPConn == nil {
+		return
+	}
+	buf := make([]byte, 1500)
+	for {
+		s.sessionsMtx.RLock()
+		_, exists := s.sessions[session.ID]
+		s.sessionsMtx.RUnlock()
+		if !exists {
+			return
+		}
+
+		session.RTPConn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		n, _, err := session.RTPConn.ReadFromUDP(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				continue
+			}
+			return
+		}
+		s.ingestRTPPacket(session, buf[:n])
+	}
+}
+
+// ingestRTPPacket은 퍼블리셔로부터 받은 RTP 패킷 하나를 H264 NAL 단위로 역패킷화합니다
+// (RFC 6184 STAP-A/FU-A). 재조합된 각 NAL은 emitH264NALU를 통해 기존 팬아웃
+// (Config.cast)으로 전달됩니다.
+func (s *RTSPServer) ingestRTPPacket(session *RTSPSession, raw []byte) {
+	hdr, payload, ok := parseRTPPacket(raw)
+	if !ok || len(payload) == 0 {
+		return
+	}
+	if !session.haveVideoBaseTS {
+		session.videoBaseTS = hdr.Timestamp
+		session.haveVideoBaseTS = true
+	}
+
+	naluType := payload[0] & 0x1F
+	switch {
+	case naluType >= 1 && naluType <= 23:
+		s.emitH264NALU(session, payload, hdr.Timestamp)
+
+	case naluType == 24: // STAP-A
+		buf := payload[1:]
+		for len(buf) >= 2 {
+			size := int(binary.BigEndian.Uint16(buf[:2]))
+			buf = buf[2:]
+			if len(buf) < size {
+				return
+			}
+			s.emitH264NALU(session, buf[:size], hdr.Timestamp)
+			buf = buf[size:]
+		}
+
+	case naluType == 28: // FU-A
+		if len(payload) < 2 {
+			return
+		}
+		fuHeader := payload[1]
+		start := fuHeader&0x80 != 0
+		end := fuHeader&0x40 != 0
+		fragType := fuHeader & 0x1F
+		if start {
+			session.fuBuffer = append([]byte{(payload[0] & 0xE0) | fragType}, payload[2:]...)
+		} else if len(session.fuBuffer) > 0 {
+			session.fuBuffer = append(session.fuBuffer, payload[2:]...)
+		}
+		if end && len(session.fuBuffer) > 0 {
+			s.emitH264NALU(session, session.fuBuffer, hdr.Timestamp)
+			session.fuBuffer = nil
+		}
+	}
+}
+
+// emitH264NALU는 재조합된 NAL 단위 하나를 av.Packet으로 감싸 팬아웃합니다. SPS/PPS(7/8)는
+// ANNOUNCE 단계에서 이미 codec data로 반영했으므로 다시 전달하지 않습니다. rtspv2가 내놓는
+// 패킷과 마찬가지로 AVCC(4바이트 빅엔디안 길이 접두)로 감싸, mp4f/webrtcv3 등 기존 팬아웃
+// 소비자가 기대하는 형식과 맞춥니다. PTS는 퍼블리셔가 보낸 RTP 타임스탬프를 첫 패킷
+// 기준 상대값으로 환산해 구합니다(벽시계 기준이면 네트워크 지터가 그대로 PTS에 묻습니다).
+func (s *RTSPServer) emitH264NALU(session *RTSPSession, nalu []byte, rtpTimestamp uint32) {
+	if len(nalu) == 0 {
+		return
+	}
+	naluType := nalu[0] & 0x1F
+	if naluType == 7 || naluType == 8 {
+		return
+	}
+
+	data := make([]byte, 4+len(nalu))
+	binary.BigEndian.PutUint32(data[:4], uint32(len(nalu)))
+	copy(data[4:], nalu)
+
+	pkt := av.Packet{
+		Idx:        0,
+		IsKeyFrame: naluType == 5,
+		Data:       data,
+		Time:       rtpPTS(session.videoBaseTS, rtpTimestamp, rtpClockRate),
+	}
+	Config.cast(session.StreamUUID, pkt)
+}
+
+// ingestAACRTPPacket은 RFC 3640 "AAC-hbr" 모드(mpeg4-generic, sizelength=13,
+// indexlength=3, indexdeltalength=3 — buildRTSPDescribeSDP가 광고하는 것과 동일한
+// 파라미터)로 패킹된 오디오 RTP 패킷을 역패킷화합니다. AU-헤더 영역에서 각 AU의
+// 길이를 읽어 그 뒤에 이어지는 AU 데이터 구간을 잘라내 emitAACAU로 전달합니다.
+func (s *RTSPServer) ingestAACRTPPacket(session *RTSPSession, raw []byte) {
+	hdr, payload, ok := parseRTPPacket(raw)
+	if !ok || len(payload) < 2 {
+		return
+	}
+	if !session.haveAudioBaseTS {
+		session.audioBaseTS = hdr.Timestamp
+		session.haveAudioBaseTS = true
+	}
+
+	auHeadersLengthBits := int(binary.BigEndian.Uint16(payload[0:2]))
+	auHeadersLength := (auHeadersLengthBits + 7) / 8
+	if len(payload) < 2+auHeadersLength {
+		return
+	}
+	auHeaders := payload[2 : 2+auHeadersLength]
+	auData := payload[2+auHeadersLength:]
+
+	// 각 AU-헤더는 2바이트: 13비트 AU 크기 + 3비트 AU-인덱스(delta).
+	for i := 0; i+2 <= len(auHeaders); i += 2 {
+		sizeAndIndex := binary.BigEndian.Uint16(auHeaders[i : i+2])
+		size := int(sizeAndIndex >> 3)
+		if size <= 0 || len(auData) < size {
+			return
+		}
+		s.emitAACAU(session, auData[:size], hdr.Timestamp)
+		auData = auData[size:]
+	}
+}
+
+// emitAACAU는 역패킷화된 AAC access unit 하나를 av.Packet으로 감싸 팬아웃합니다.
+// aacparser/mpegts 등 기존 소비자는 ADTS가 아닌 raw AAC 프레임을 기대하므로 추가
+// 프레이밍 없이 그대로 전달합니다. 오디오 트랙은 buildCodecData에서 항상 인덱스 1에
+// 추가하므로 Idx를 1로 고정합니다.
+func (s *RTSPServer) emitAACAU(session *RTSPSession, au []byte, rtpTimestamp uint32) {
+	if len(au) == 0 {
+		return
+	}
+	clockRate := session.audioClockRate
+	if clockRate <= 0 {
+		clockRate = rtpClockRate
+	}
+
+	data := make([]byte, len(au))
+	copy(data, au)
+
+	pkt := av.Packet{
+		Idx:        1,
+		IsKeyFrame: true,
+		Data:       data,
+		Time:       rtpPTS(session.audioBaseTS, rtpTimestamp, clockRate),
+	}
+	Config.cast(session.StreamUUID, pkt)
+}
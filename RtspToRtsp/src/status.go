@@ -140,7 +140,9 @@ func HandleServerStats(w http.ResponseWriter, r *http.Request) {
 	// StreamST 구조체의 Cl 필드를 참고하여 시청자 수 계산
 	Config.mutex.RLock()
 	for _, stream := range Config.Streams {
-		stats.ViewerCount += len(stream.Cl)
+		if stream.Ring != nil {
+			stats.ViewerCount += stream.Ring.ReaderCount()
+		}
 	}
 	Config.mutex.RUnlock()
 
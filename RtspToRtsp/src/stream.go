@@ -2,7 +2,8 @@ package main
 
 import (
 	"errors"
-	"log"
+	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/deepch/vdk/av"
@@ -14,18 +15,101 @@ var (
 	ErrorStreamExitRtspDisconnect  = errors.New("Stream Exit Rtsp Disconnect")
 	ErrorStreamExitNoViewer        = errors.New("Stream Exit On Demand No Viewer")
 	ErrorStreamExitStatusFalse     = errors.New("Stream Exit Status False")
+	ErrorStreamExitStopRequested   = errors.New("Stream Exit Stop Requested")
 )
 
+const (
+	reconnectBaseBackoff = 1 * time.Second
+	reconnectMaxBackoff  = 30 * time.Second
+
+	// noVideoDowngradeThreshold는 연속으로 이만큼 ErrorStreamExitNoVideoOnStream이
+	// 나면 업스트림이 비디오 트랙을 너무 늦게 보내는 것으로 보고 DialTimeout을 늘려
+	// 재시도합니다(타임아웃이 짧아 키프레임 도착 전에 끊기는 카메라를 위함).
+	noVideoDowngradeThreshold = 3
+	dialTimeoutDowngraded     = 15 * time.Second
+)
+
+// streamStopRegistry는 RTSPWorkerLoop가 실행 중인 이름별 stop 채널을 보관합니다.
+// StopStreamWorker로 reconnect 루프를 20초 워치독 타이머를 기다리지 않고 즉시
+// 끝낼 수 있게 합니다.
+var (
+	streamStopMtx sync.Mutex
+	streamStopChs = map[string]chan bool{}
+)
+
+// StopStreamWorker는 name으로 실행 중인 RTSPWorkerLoop에 즉시 종료를 요청합니다.
+// 해당 이름으로 실행 중인 워커가 없으면 false를 반환합니다.
+func StopStreamWorker(name string) bool {
+	streamStopMtx.Lock()
+	stopCh, ok := streamStopChs[name]
+	streamStopMtx.Unlock()
+	if !ok {
+		return false
+	}
+	select {
+	case stopCh <- true:
+	default:
+	}
+	return true
+}
+
 func serveStreams() {
 	for k, v := range Config.Streams {
 		RegisterStream(k, v.URL, v.OnDemand)
+		RunOnInitIfConfigured(k, v)
 		if !v.OnDemand {
 			go RTSPWorkerLoop(k, v.URL, v.OnDemand, v.DisableAudio, v.Debug)
 		}
+		for subName, sub := range v.SubStreams {
+			registerSubStream(k, subName, sub, v.OnDemand)
+		}
+	}
+}
+
+// subStreamUUID는 메인 스트림 이름과 서브 스트림 이름으로부터 Config.Streams의
+// 합성 키를 만듭니다. 기존의 단일 스트림 name 문자열 키를 그대로 재사용하므로
+// RTSPWorkerLoop/RTSPWorker, HLS, 메트릭 등 name 하나만 알면 되는 모든 기존
+// 경로가 변경 없이 서브 스트림도 동일하게 다룹니다.
+func subStreamUUID(name, subName string) string {
+	return name + "/" + subName
+}
+
+// registerSubStream은 서브 스트림을 subStreamUUID(name, subName) 합성 키로
+// Config.Streams에 독립적인 항목으로 등록하고, 그 키로 자신만의 RTSPWorkerLoop
+// 고루틴을 기동합니다. 메인 스트림과 완전히 분리된 상태(IsRunning/Status/
+// ReconnectCount/Ring/시청자 목록)를 가지므로, 메인 스트림의 워치독이 멈추거나
+// 재연결 중이어도 서브 스트림은 영향을 받지 않습니다.
+func registerSubStream(name, subName string, sub SubStreamConfig, onDemand bool) {
+	uuid := subStreamUUID(name, subName)
+
+	Config.mutex.Lock()
+	if _, ok := Config.Streams[uuid]; !ok {
+		Config.Streams[uuid] = StreamST{
+			URL:          sub.URL,
+			OnDemand:     onDemand,
+			DisableAudio: sub.DisableAudio,
+			Debug:        sub.Debug,
+			Ring:         NewRingBuffer(streamRingBufferSize),
+		}
+	}
+	Config.mutex.Unlock()
+
+	RegisterStream(uuid, sub.URL, onDemand)
+	if !onDemand {
+		go RTSPWorkerLoop(uuid, sub.URL, onDemand, sub.DisableAudio, sub.Debug)
 	}
 }
 func RTSPWorkerLoop(name, url string, OnDemand, DisableAudio, Debug bool) {
+	stopCh := make(chan bool, 1)
+	streamStopMtx.Lock()
+	streamStopChs[name] = stopCh
+	streamStopMtx.Unlock()
+
 	defer func() {
+		streamStopMtx.Lock()
+		delete(streamStopChs, name)
+		streamStopMtx.Unlock()
+
 		// 루프가 종료될 때 is_running을 false로 설정
 		Config.mutex.Lock()
 		if stream, ok := Config.Streams[name]; ok {
@@ -48,14 +132,40 @@ func RTSPWorkerLoop(name, url string, OnDemand, DisableAudio, Debug bool) {
 	}
 	Config.mutex.Unlock()
 
+	// 재연결은 지수 백오프(+ 지터)로 재시도합니다. 연속 실패가 쌓일수록 대기 시간을
+	// 늘려 죽은 업스트림에 계속 들이받는 것을 막고, 직전 시도가 reconnectHealthyAfter
+	// 이상 유지되었으면(정상 스트리밍 후 끊김) backoff를 초기값으로 되돌립니다.
+	const reconnectHealthyAfter = 30 * time.Second
+	backoff := reconnectBaseBackoff
+
+	// consecutiveNoVideo는 연속으로 ErrorStreamExitNoVideoOnStream이 난 횟수입니다.
+	// noVideoDowngradeThreshold에 도달하면 다음 RTSPWorker 시도에 더 긴 DialTimeout을
+	// 줘서, 키프레임이 늦게 도착하는 업스트림이 매번 같은 타임아웃으로 끊기는 것을 막습니다.
+	consecutiveNoVideo := 0
+
 	for {
-		log.Println("Stream Try Connect", name)
-		err := RTSPWorker(name, url, OnDemand, DisableAudio, Debug)
+		Info("Stream Try Connect", name)
+		dialTimeout := 5 * time.Second
+		if consecutiveNoVideo >= noVideoDowngradeThreshold {
+			dialTimeout = dialTimeoutDowngraded
+		}
+		attemptStart := time.Now()
+		err := RTSPWorker(name, url, OnDemand, DisableAudio, Debug, dialTimeout, stopCh)
+		if err == ErrorStreamExitStopRequested {
+			Info("Stream Stop Requested", name)
+			return
+		}
 		if err != nil {
-			log.Println(err)
+			Error(err)
 			Config.LastError = err
 			Config.HandleStreamError(name, err)
 
+			if err == ErrorStreamExitNoVideoOnStream {
+				consecutiveNoVideo++
+			} else {
+				consecutiveNoVideo = 0
+			}
+
 			// 재연결 시도 횟수 증가
 			Config.mutex.Lock()
 			if stream, ok := Config.Streams[name]; ok {
@@ -65,16 +175,35 @@ func RTSPWorkerLoop(name, url string, OnDemand, DisableAudio, Debug bool) {
 				Config.Streams[name] = stream
 			}
 			Config.mutex.Unlock()
+			recordReconnect(name)
+		} else {
+			consecutiveNoVideo = 0
+		}
+		healthy := err == nil || time.Since(attemptStart) >= reconnectHealthyAfter
+		if healthy {
+			backoff = reconnectBaseBackoff
 		}
 
 		if OnDemand && !Config.HasViewer(name) {
-			log.Println(ErrorStreamExitNoViewer)
+			Info(ErrorStreamExitNoViewer)
+			return
+		}
+
+		select {
+		case <-stopCh:
+			Info("Stream Stop Requested", name)
 			return
+		case <-time.After(backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))):
+		}
+		if !healthy {
+			backoff *= 2
+			if backoff > reconnectMaxBackoff {
+				backoff = reconnectMaxBackoff
+			}
 		}
-		time.Sleep(1 * time.Second)
 	}
 }
-func RTSPWorker(name, url string, OnDemand, DisableAudio, Debug bool) error {
+func RTSPWorker(name, url string, OnDemand, DisableAudio, Debug bool, dialTimeout time.Duration, stopCh chan bool) error {
 	keyTest := time.NewTimer(20 * time.Second)
 	clientTest := time.NewTimer(20 * time.Second)
 
@@ -90,7 +219,7 @@ func RTSPWorker(name, url string, OnDemand, DisableAudio, Debug bool) error {
 	RTSPClient, err := rtspv2.Dial(rtspv2.RTSPClientOptions{
 		URL:              url,
 		DisableAudio:     DisableAudio,
-		DialTimeout:      5 * time.Second, // 타임아웃 증가
+		DialTimeout:      dialTimeout,
 		ReadWriteTimeout: 5 * time.Second, // 타임아웃 증가
 		Debug:            Debug,
 	})
@@ -121,14 +250,21 @@ func RTSPWorker(name, url string, OnDemand, DisableAudio, Debug bool) error {
 
 			// 시청자 수 업데이트
 			Config.mutex.RLock()
-			viewerCount := len(Config.Streams[name].Cl)
+			stream := Config.Streams[name]
 			Config.mutex.RUnlock()
+			viewerCount := 0
+			if stream.Ring != nil {
+				viewerCount = stream.Ring.ReaderCount()
+			}
 			Config.UpdateViewerCount(name, viewerCount)
 		}
 	}()
 
 	for {
 		select {
+		case <-stopCh:
+			return ErrorStreamExitStopRequested
+
 		case <-clientTest.C:
 			if OnDemand {
 				if !Config.HasViewer(name) {
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gopsutilcpu "github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	gopsutilnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// InterfaceStats는 인터페이스 한 개에 대한 네트워크 카운터입니다.
+type InterfaceStats struct {
+	Name            string  `json:"name"`
+	BytesSent       uint64  `json:"bytesSent"`
+	BytesReceived   uint64  `json:"bytesReceived"`
+	PacketsSent     uint64  `json:"packetsSent"`
+	PacketsReceived uint64  `json:"packetsReceived"`
+	BytesSentRate   float64 `json:"bytesSentRate"`
+	BytesRecvRate   float64 `json:"bytesReceivedRate"`
+}
+
+// prevInterfaceStats/prevInterfaceStatsTime는 5초 폴링 티커와 /stream/api/server/status
+// 요청 핸들러 양쪽에서 동시에 읽고 쓸 수 있으므로 prevInterfaceStatsMu로 보호합니다.
+var (
+	prevInterfaceStatsMu   sync.Mutex
+	prevInterfaceStats     = map[string]gopsutilnet.IOCountersStat{}
+	prevInterfaceStatsTime = time.Now()
+)
+
+// collectCPUStats는 CPU 코어별 사용률(%)을 gopsutil로 수집합니다.
+func collectCPUStats() ([]float64, error) {
+	return gopsutilcpu.Percent(200*time.Millisecond, true)
+}
+
+// collectLoadAverage는 Linux/macOS/BSD에서 동일하게 동작하는 평균 부하를 반환합니다.
+// Windows에서는 gopsutil이 부하 평균을 지원하지 않으므로 0을 반환합니다.
+func collectLoadAverage() (float64, float64, float64, error) {
+	avg, err := load.Avg()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return avg.Load1, avg.Load5, avg.Load15, nil
+}
+
+// collectMemoryStats는 실제 시스템 RAM 사용량을 반환합니다 (Go 런타임이 아닌 OS 기준).
+func collectMemoryStats() (*mem.VirtualMemoryStat, error) {
+	return mem.VirtualMemory()
+}
+
+// collectProcessStats는 현재 프로세스의 RSS/VSZ/FD/스레드 수를 gopsutil로 수집합니다.
+func collectProcessStats() (numFD int32, numThreads int32, vsz, rss uint64, cpuPercent float64, err error) {
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		return 0, 0, 0, 0, 0, err
+	}
+
+	if n, ferr := proc.NumFDs(); ferr == nil {
+		numFD = n
+	}
+	if n, terr := proc.NumThreads(); terr == nil {
+		numThreads = n
+	}
+	if memInfo, merr := proc.MemoryInfo(); merr == nil && memInfo != nil {
+		vsz = memInfo.VMS
+		rss = memInfo.RSS
+	}
+	if pct, cerr := proc.CPUPercent(); cerr == nil {
+		cpuPercent = pct
+	}
+	return numFD, numThreads, vsz, rss, cpuPercent, nil
+}
+
+// collectUptime은 호스트의 부팅 후 경과 시간을 초 단위로 반환합니다.
+func collectUptime() (uint64, error) {
+	return host.Uptime()
+}
+
+// collectNetworkStats는 설정된 인터페이스(Config.Server.NetworkInterfaces가 비어있으면 전체,
+// 루프백 제외)의 송수신 바이트/패킷 수와 초당 전송률을 per-interface로 수집합니다.
+func collectNetworkStats() (map[string]InterfaceStats, error) {
+	counters, err := gopsutilnet.IOCounters(true)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := Config.Server.NetworkInterfaces
+	now := time.Now()
+
+	prevInterfaceStatsMu.Lock()
+	defer prevInterfaceStatsMu.Unlock()
+
+	elapsed := now.Sub(prevInterfaceStatsTime).Seconds()
+
+	result := make(map[string]InterfaceStats)
+	for _, c := range counters {
+		if strings.HasPrefix(c.Name, "lo") {
+			continue
+		}
+		if len(selected) > 0 && !containsString(selected, c.Name) {
+			continue
+		}
+
+		stat := InterfaceStats{
+			Name:            c.Name,
+			BytesSent:       c.BytesSent,
+			BytesReceived:   c.BytesRecv,
+			PacketsSent:     c.PacketsSent,
+			PacketsReceived: c.PacketsRecv,
+		}
+
+		if prev, ok := prevInterfaceStats[c.Name]; ok && elapsed > 0 {
+			stat.BytesSentRate = float64(c.BytesSent-prev.BytesSent) / elapsed
+			stat.BytesRecvRate = float64(c.BytesRecv-prev.BytesRecv) / elapsed
+		}
+		result[c.Name] = stat
+		prevInterfaceStats[c.Name] = c
+	}
+	prevInterfaceStatsTime = now
+
+	return result, nil
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func logSysstatError(what string, err error) {
+	Errorf("%s 수집 실패: %v", what, err)
+}
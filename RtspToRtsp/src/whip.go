@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	webrtc "github.com/deepch/vdk/format/webrtcv3"
+	"github.com/gin-gonic/gin"
+)
+
+// whipSession은 진행 중인 WHIP/WHEP 세션을 추적하기 위한 구조체입니다. 두 종류 모두
+// 이 구조체와 whipSessions 맵을 함께 씁니다.
+type whipSession struct {
+	uuid   string
+	muxer  *webrtc.Muxer
+	reader *RingReader
+	cancel chan bool
+}
+
+var (
+	whipSessions   = make(map[string]*whipSession)
+	whipSessionsMu sync.RWMutex
+)
+
+// HTTPAPIServerWHIPOptions는 WHIP/WHEP용 OPTIONS 요청에 대해 구성된 ICE 서버를
+// Link 헤더(rel="ice-server")로 광고합니다.
+func HTTPAPIServerWHIPOptions(c *gin.Context) {
+	for _, server := range Config.GetICEServers() {
+		link := fmt.Sprintf(`<%s>; rel="ice-server"`, server)
+		c.Writer.Header().Add("Link", link)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// HTTPAPIServerWHIPPublish는 IETF WHIP에 따라 외부 퍼블리셔(e.g. OBS)가 SDP offer를
+// POST하면 answer SDP를 201 Created로 반환하고, 해당 스트림을 Config.Streams에 등록합니다.
+func HTTPAPIServerWHIPPublish(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if c.GetHeader("Content-Type") != "application/sdp" {
+		c.String(http.StatusUnsupportedMediaType, "Content-Type must be application/sdp")
+		return
+	}
+
+	offer, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.String(http.StatusBadRequest, "failed to read SDP offer: %v", err)
+		return
+	}
+
+	Config.mutex.Lock()
+	if _, ok := Config.Streams[uuid]; !ok {
+		Config.Streams[uuid] = StreamST{
+			URL:      "publisher",
+			OnDemand: false,
+			Ring:     NewRingBuffer(streamRingBufferSize),
+		}
+	}
+	Config.mutex.Unlock()
+
+	muxerWebRTC := webrtc.NewMuxer(webrtc.Options{
+		ICEServers:    Config.GetICEServers(),
+		ICEUsername:   Config.GetICEUsername(),
+		ICECredential: Config.GetICECredential(),
+		PortMin:       Config.GetWebRTCPortMin(),
+		PortMax:       Config.GetWebRTCPortMax(),
+	})
+
+	answer, err := muxerWebRTC.WriteHeader(nil, base64.StdEncoding.EncodeToString(offer))
+	if err != nil {
+		Error("WHIP WriteHeader", err)
+		c.String(http.StatusInternalServerError, "failed to negotiate WHIP session: %v", err)
+		return
+	}
+	answerSDP, err := base64.StdEncoding.DecodeString(answer)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "failed to decode answer SDP: %v", err)
+		return
+	}
+
+	resourceID := pseudoUUID()
+	whipSessionsMu.Lock()
+	whipSessions[resourceID] = &whipSession{uuid: uuid, muxer: muxerWebRTC, cancel: make(chan bool, 1)}
+	whipSessionsMu.Unlock()
+
+	go readPublishedPackets(uuid, resourceID, muxerWebRTC)
+
+	c.Header("Location", "/whip/"+uuid+"/"+resourceID)
+	c.Header("Content-Type", "application/sdp")
+	c.String(http.StatusCreated, "%s", answerSDP)
+	Info("WHIP publish started for stream", uuid)
+}
+
+// readPublishedPackets는 WHIP 퍼블리셔로부터 수신한 패킷을 디코딩하여
+// 기존 RTSP 풀 스트림과 동일한 방식(Config.coAd/Config.cast)으로 팬아웃합니다.
+func readPublishedPackets(uuid, resourceID string, muxerWebRTC *webrtc.Muxer) {
+	codecs, err := muxerWebRTC.ReadHeader()
+	if err == nil && codecs != nil {
+		Config.coAd(uuid, codecs)
+	}
+	for {
+		whipSessionsMu.RLock()
+		sess, ok := whipSessions[resourceID]
+		whipSessionsMu.RUnlock()
+		if !ok {
+			return
+		}
+		select {
+		case <-sess.cancel:
+			return
+		default:
+		}
+
+		pkt, err := muxerWebRTC.ReadPacket()
+		if err != nil {
+			Error("WHIP ReadPacket", err)
+			removeWHIPSession(resourceID)
+			return
+		}
+		Config.cast(uuid, pkt)
+	}
+}
+
+func removeWHIPSession(resourceID string) {
+	whipSessionsMu.Lock()
+	sess, ok := whipSessions[resourceID]
+	if ok {
+		delete(whipSessions, resourceID)
+	}
+	whipSessionsMu.Unlock()
+	if ok {
+		sess.muxer.Close()
+		Config.mutex.Lock()
+		delete(Config.Streams, sess.uuid)
+		Config.mutex.Unlock()
+		Info("WHIP session torn down for stream", sess.uuid)
+	}
+}
+
+// HTTPAPIServerWHIPPatch는 WHIP/WHEP 세션에 ICE trickle candidate를 전달합니다.
+func HTTPAPIServerWHIPPatch(c *gin.Context) {
+	if c.GetHeader("Content-Type") != "application/trickle-ice-sdpfrag" {
+		c.String(http.StatusUnsupportedMediaType, "Content-Type must be application/trickle-ice-sdpfrag")
+		return
+	}
+	// vdk webrtcv3 Muxer는 offer/answer 교환 시점에 ICE candidate를 모두 수집하므로
+	// 추가 trickle candidate는 단순히 수신만 확인합니다.
+	io.Copy(io.Discard, c.Request.Body)
+	c.Status(http.StatusNoContent)
+}
+
+// HTTPAPIServerWHIPDelete는 WHIP(퍼블리시) 세션을 종료합니다.
+func HTTPAPIServerWHIPDelete(c *gin.Context) {
+	resourceID := c.Param("resource")
+	whipSessionsMu.RLock()
+	sess, ok := whipSessions[resourceID]
+	whipSessionsMu.RUnlock()
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	sess.cancel <- true
+	removeWHIPSession(resourceID)
+	c.Status(http.StatusOK)
+}
+
+// HTTPAPIServerWHEPPlay는 IETF WHEP에 따라 플레이어가 제출한 SDP offer에
+// answer SDP를 201 Created로 반환하고, 기존 구독 채널(Config.clAd)로부터
+// 패킷을 받아 재생을 시작합니다.
+func HTTPAPIServerWHEPPlay(c *gin.Context) {
+	uuid := c.Param("uuid")
+	if !Config.ext(uuid) {
+		c.String(http.StatusNotFound, "stream not found")
+		return
+	}
+	if c.GetHeader("Content-Type") != "application/sdp" {
+		c.String(http.StatusUnsupportedMediaType, "Content-Type must be application/sdp")
+		return
+	}
+
+	offer, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.String(http.StatusBadRequest, "failed to read SDP offer: %v", err)
+		return
+	}
+
+	Config.RunIFNotRun(uuid)
+	codecs := Config.coGe(uuid)
+	if codecs == nil {
+		c.String(http.StatusServiceUnavailable, "stream codec not ready")
+		return
+	}
+
+	muxerWebRTC := webrtc.NewMuxer(webrtc.Options{
+		ICEServers:    Config.GetICEServers(),
+		ICEUsername:   Config.GetICEUsername(),
+		ICECredential: Config.GetICECredential(),
+		PortMin:       Config.GetWebRTCPortMin(),
+		PortMax:       Config.GetWebRTCPortMax(),
+	})
+
+	answer, err := muxerWebRTC.WriteHeader(codecs, base64.StdEncoding.EncodeToString(offer))
+	if err != nil {
+		Error("WHEP WriteHeader", err)
+		c.String(http.StatusInternalServerError, "failed to negotiate WHEP session: %v", err)
+		return
+	}
+	answerSDP, err := base64.StdEncoding.DecodeString(answer)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "failed to decode answer SDP: %v", err)
+		return
+	}
+
+	reader := Config.clAd(uuid, "whep")
+	resourceID := pseudoUUID()
+	whipSessionsMu.Lock()
+	whipSessions[resourceID] = &whipSession{uuid: uuid, muxer: muxerWebRTC, reader: reader, cancel: make(chan bool, 1)}
+	whipSessionsMu.Unlock()
+
+	AudioOnly := len(codecs) == 1 && codecs[0].Type().IsAudio()
+	go writeWHEPPackets(uuid, resourceID, reader, muxerWebRTC, AudioOnly)
+
+	c.Header("Location", "/whep/"+uuid+"/"+resourceID)
+	c.Header("Content-Type", "application/sdp")
+	c.String(http.StatusCreated, "%s", answerSDP)
+	Info("WHEP play started for stream", uuid)
+}
+
+// writeWHEPPackets는 구독 채널에서 받은 패킷을 키프레임 대기 후 WebRTC로 전달합니다
+// (HTTPAPIServerStreamWebRTC2의 재생 루프와 동일한 패턴입니다).
+func writeWHEPPackets(uuid, resourceID string, reader *RingReader, muxerWebRTC *webrtc.Muxer, audioOnly bool) {
+	defer removeWHEPSession(resourceID)
+	var videoStart bool
+	noVideo := time.NewTimer(10 * time.Second)
+	defer noVideo.Stop()
+	for {
+		pck, ok := reader.Read(10 * time.Second)
+		if !ok {
+			Debug("WHEP noVideo", uuid)
+			return
+		}
+		select {
+		case <-noVideo.C:
+			Debug("WHEP noVideo", uuid)
+			return
+		default:
+		}
+		if pck.IsKeyFrame || audioOnly {
+			noVideo.Reset(10 * time.Second)
+			videoStart = true
+		}
+		if !videoStart && !audioOnly {
+			continue
+		}
+		if err := muxerWebRTC.WritePacket(pck); err != nil {
+			Error("WHEP WritePacket", err)
+			return
+		}
+	}
+}
+
+func removeWHEPSession(resourceID string) {
+	whipSessionsMu.Lock()
+	sess, ok := whipSessions[resourceID]
+	if ok {
+		delete(whipSessions, resourceID)
+	}
+	whipSessionsMu.Unlock()
+	if ok {
+		Config.clDe(sess.uuid, "whep", sess.reader)
+		sess.muxer.Close()
+	}
+}
+
+// HTTPAPIServerWHEPDelete는 WHEP(재생) 세션을 종료합니다.
+func HTTPAPIServerWHEPDelete(c *gin.Context) {
+	resourceID := c.Param("resource")
+	whipSessionsMu.RLock()
+	_, ok := whipSessions[resourceID]
+	whipSessionsMu.RUnlock()
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	removeWHEPSession(resourceID)
+	c.Status(http.StatusOK)
+}
+
+// registerWHIPRoutes는 /whip/:uuid, /whep/:uuid 엔드포인트를 등록합니다.
+func registerWHIPRoutes(router *gin.Engine) {
+	router.OPTIONS("/whip/:uuid", HTTPAPIServerWHIPOptions)
+	router.POST("/whip/:uuid", HTTPAPIServerWHIPPublish)
+	router.PATCH("/whip/:uuid/:resource", HTTPAPIServerWHIPPatch)
+	router.DELETE("/whip/:uuid/:resource", HTTPAPIServerWHIPDelete)
+
+	router.OPTIONS("/whep/:uuid", HTTPAPIServerWHIPOptions)
+	router.POST("/whep/:uuid", HTTPAPIServerWHEPPlay)
+	router.PATCH("/whep/:uuid/:resource", HTTPAPIServerWHIPPatch)
+	router.DELETE("/whep/:uuid/:resource", HTTPAPIServerWHEPDelete)
+}